@@ -11,8 +11,8 @@ fn main() {
         return;
     }
 
-    // 告诉 Cargo 如果 go_ffi/ffi.go 发生变化，就重新运行 build.rs
-    println!("cargo:rerun-if-changed=src/go_ffi/ffi.go");
+    // 告诉 Cargo 如果 go_ffi 目录下的 Go 源码发生变化，就重新运行 build.rs
+    println!("cargo:rerun-if-changed=src/go_ffi");
 
     // 获取 Cargo 的 OUT_DIR (输出目录)
     let lib_out_path = PathBuf::from(env::var("OUT_DIR").unwrap());
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells 限制行差异计算使用的表格大小（两段不同内容行数的乘积），超出时不再逐行对齐，
+// 而是直接列出两段不同的内容，避免比较大段输出时占用过多内存
+const maxDiffCells = 1 << 20
+
+// renderAndAssert 渲染模板并与 expected 比较，相同时 output 和 error 都为空，
+// 不同时 error 为逐行的差异。normalizeWhitespace 为 true 时按 normalizeForCompare 宽松比较。
+func renderAndAssert(templateContent string, jsonData string, expected string, normalizeWhitespace bool, opts renderOptions) RenderResult {
+	result := renderGoTemplate(templateContent, jsonData, opts)
+	if result.Error != "" {
+		return result
+	}
+
+	actual := result.Output
+	if normalizeWhitespace {
+		expected = normalizeForCompare(expected)
+		actual = normalizeForCompare(actual)
+	}
+	if actual == expected {
+		return RenderResult{}
+	}
+	return RenderResult{
+		Error: "rendered output does not match expected:\n" + lineDiff(expected, actual),
+	}
+}
+
+// normalizeForCompare 统一换行符为 LF，去掉每行首尾的空白并将行内连续的空格和制表符合并为一个空格，
+// 同时忽略开头和结尾的空行
+func normalizeForCompare(s string) string {
+	lines := strings.Split(normalizeLineEndings(s, "lf"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '\t' }), " ")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// lineDiff 返回 expected 与 actual 的逐行差异："-" 开头的行只在 expected 中，"+" 开头的行只在 actual 中，
+// 空格开头的行两者相同。相同的开头和结尾不会列出，第一行标出首个不同行的行号（从 1 开始）。
+func lineDiff(expected string, actual string) string {
+	a := strings.Split(expected, "\n")
+	b := strings.Split(actual, "\n")
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a = a[prefix : len(a)-suffix]
+	b = b[prefix : len(b)-suffix]
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- expected\n+++ actual\n@@ line %d @@\n", prefix+1)
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			out.WriteString("-" + line + "\n")
+		}
+		for _, line := range b {
+			out.WriteString("+" + line + "\n")
+		}
+		return strings.TrimSuffix(out.String(), "\n")
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("-" + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+" + b[j] + "\n")
+			j++
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// lineDiff 只列出首尾相同部分之间的差异，按最长公共子序列对齐，相同的行以空格开头
+func TestLineDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		want     string
+	}{
+		{"changed line", "a\nb\nc", "a\nx\nc", "--- expected\n+++ actual\n@@ line 2 @@\n-b\n+x"},
+		{"added line", "a\nc", "a\nb\nc", "--- expected\n+++ actual\n@@ line 2 @@\n+b"},
+		{"removed line", "a\nb\nc", "a\nc", "--- expected\n+++ actual\n@@ line 2 @@\n-b"},
+		{"first line", "a\nb", "x\nb", "--- expected\n+++ actual\n@@ line 1 @@\n-a\n+x"},
+		{"common middle", "a\nkeep\nb", "x\nkeep\ny", "--- expected\n+++ actual\n@@ line 1 @@\n-a\n+x\n keep\n-b\n+y"},
+		{"moved line", "a\nb\nc", "b\nc\na", "--- expected\n+++ actual\n@@ line 1 @@\n-a\n b\n c\n+a"},
+		{"trailing newline", "a\n", "a", "--- expected\n+++ actual\n@@ line 2 @@\n-"},
+		{"from empty", "", "a\nb", "--- expected\n+++ actual\n@@ line 1 @@\n-\n+a\n+b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.expected, tt.actual); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// 两段不同内容的行数乘积超过 maxDiffCells 时不再对齐，先列出全部删除的行再列出全部新增的行
+func TestLineDiffLargeFallback(t *testing.T) {
+	n := 1025
+	expected := make([]string, n)
+	actual := make([]string, n)
+	for i := range expected {
+		expected[i] = "e"
+		actual[i] = "a"
+	}
+	// 中间的公共行在对齐时会以空格开头，不对齐时则作为删除和新增的行出现
+	expected[n/2] = "same"
+	actual[n/2] = "same"
+
+	got := lineDiff(strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	lines := strings.Split(got, "\n")
+	if len(lines) != 3+2*n {
+		t.Fatalf("got %d lines, want %d", len(lines), 3+2*n)
+	}
+	if lines[2] != "@@ line 1 @@" || lines[3] != "-e" || lines[3+n] != "+a" {
+		t.Errorf("unexpected fallback diff start: %q", lines[:4])
+	}
+	if lines[3+n/2] != "-same" || lines[3+n+n/2] != "+same" {
+		t.Errorf("fallback diff should list the common line as removed and added")
+	}
+}
+
+// normalizeForCompare 统一换行符，去掉行首尾空白，合并行内空白，并忽略开头和结尾的空行
+func TestNormalizeForCompare(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"a  b\t c", "a b c"},
+		{"  a \r\n\tb\t", "a\nb"},
+		{"\n\n a\n\nb\n\n", "a\n\nb"},
+		{"a\rb", "a\nb"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeForCompare(tt.in); got != tt.want {
+			t.Errorf("normalizeForCompare(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// 相同时 output 和 error 都为空，不同时 error 为差异，渲染失败时返回渲染错误
+func TestRenderAndAssert(t *testing.T) {
+	tests := []struct {
+		name      string
+		template  string
+		expected  string
+		normalize bool
+		wantErr   string
+	}{
+		{"equal", "a\n{{ .v }}", "a\n1", false, ""},
+		{"different", "a\n{{ .v }}", "a\n2", false, "rendered output does not match expected:\n--- expected\n+++ actual\n@@ line 2 @@\n-2\n+1"},
+		{"whitespace strict", "a  {{ .v }}\r\n", "a 1\n", false, "rendered output does not match expected:"},
+		{"whitespace normalized", "\n  a  {{ .v }}\r\n\n", "a 1", true, ""},
+		{"normalized still different", "a {{ .v }}", "a 2", true, "@@ line 1 @@\n-a 2\n+a 1"},
+		{"render error", `{{ fail "boom" }}`, "", false, templateFailurePrefix + "boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderAndAssert(tt.template, `{"v": 1}`, tt.expected, tt.normalize, renderOptions{})
+			if result.Output != "" {
+				t.Errorf("got output %q, want none", result.Output)
+			}
+			if tt.wantErr == "" && result.Error != "" {
+				t.Errorf("unexpected error: %s", result.Error)
+			}
+			if tt.wantErr != "" && !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("got error %q, want it to contain %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
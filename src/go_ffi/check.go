@@ -0,0 +1,312 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// goBuiltinFuncs 是 Go 模板预定义的函数，不需要注册即可使用
+var goBuiltinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// impureFuncs 是输出不只取决于输入数据的辅助函数，新增读取时间、环境、随机数或文件的函数时需要加入这里。
+// 计数器等渲染内的状态每次渲染都会重置，不影响确定性。
+var impureFuncs = map[string]bool{
+	// 依赖当前时间，除非设置了 referenceTime 选项
+	"timeAgo": true,
+	// 执行来自数据的模板文本，其中调用的函数（包括 timeAgo）无法通过静态分析得知
+	"renderString": true,
+}
+
+// optInFuncs 是默认不注册、需要通过渲染选项开启的辅助函数，值为对应的选项名称
+var optInFuncs = map[string]string{
+	"renderString": "allowRenderString",
+}
+
+// determinism 是 IsTemplateDeterministic 的输出
+type determinism struct {
+	Deterministic bool     `json:"deterministic"`
+	Funcs         []string `json:"funcs"`
+}
+
+// parseTrees 只做语法解析，不检查函数是否已定义，返回根模板及所有 define 块的语法树。
+// 分隔符为空时使用 {{ 和 }}。
+func parseTrees(templateContent string, leftDelim string, rightDelim string) (map[string]*parse.Tree, error) {
+	return parseNamedTrees(rootTemplateName, templateContent, leftDelim, rightDelim)
+}
+
+// parseNamedTrees 与 parseTrees 相同，但根模板使用 name 命名，错误信息中的位置也以 name 开头
+func parseNamedTrees(name string, templateContent string, leftDelim string, rightDelim string) (map[string]*parse.Tree, error) {
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := map[string]*parse.Tree{}
+	if _, err := tree.Parse(templateContent, leftDelim, rightDelim, trees); err != nil {
+		return nil, err
+	}
+	return trees, nil
+}
+
+// checkAllowedFuncs 检查模板只调用了 allowedJson（JSON 字符串数组）中列出的函数。
+// Go 模板的内置函数（如 printf、len）同样需要列在允许列表中。模板按 opts 中的分隔符解析，其余选项不起作用。
+func checkAllowedFuncs(templateContent string, allowedJson string, opts renderOptions) RenderResult {
+	var allowed []string
+	if err := json.Unmarshal([]byte(allowedJson), &allowed); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse allowed functions: %v", err),
+		}
+	}
+
+	trees, err := parseTrees(templateContent, opts.LeftDelim, opts.RightDelim)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template: %v", err),
+		}
+	}
+
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+	var disallowed []string
+	for _, name := range usedFuncs(trees) {
+		if !allowedSet[name] {
+			disallowed = append(disallowed, name)
+		}
+	}
+	if len(disallowed) > 0 {
+		return RenderResult{
+			Error: fmt.Sprintf("template uses functions that are not allowed: %s", strings.Join(disallowed, ", ")),
+		}
+	}
+	return RenderResult{}
+}
+
+// checkDefinedFuncs 一次性列出模板中调用但未在 funcs 中注册的所有函数，
+// 而不是像 Go 的解析器那样只报告遇到的第一个
+func checkDefinedFuncs(templateContent string, opts renderOptions, funcs map[string]interface{}) error {
+	trees, err := parseTrees(templateContent, opts.LeftDelim, opts.RightDelim)
+	if err != nil {
+		return err
+	}
+
+	var unknown []string
+	for _, name := range usedFuncs(trees) {
+		if _, ok := funcs[name]; !ok && !goBuiltinFuncs[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("template uses undefined functions: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// checkDeterministic 检查模板的输出是否只取决于输入数据，即没有调用 impureFuncs 中的函数，
+// 并按名称排序列出调用到的这类函数。模板按 opts 中的分隔符解析，其余选项不起作用。
+func checkDeterministic(templateContent string, opts renderOptions) RenderResult {
+	trees, err := parseTrees(templateContent, opts.LeftDelim, opts.RightDelim)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template: %v", err),
+		}
+	}
+
+	impure := []string{}
+	for _, name := range usedFuncs(trees) {
+		if impureFuncs[name] {
+			impure = append(impure, name)
+		}
+	}
+	encoded, err := encodeJSON(determinism{Deterministic: len(impure) == 0, Funcs: impure})
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode result: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
+
+// templateSetFile 是 CheckTemplateSet 报告中单个文件的检查结果，ParseError 非空时其余检查不会进行。
+// RecursiveTemplates 只是提示：递归可能是有意的（例如渲染树形数据），不会使 OK 为 false。
+type templateSetFile struct {
+	Name                string   `json:"name"`
+	ParseError          string   `json:"parseError,omitempty"`
+	UnknownFuncs        []string `json:"unknownFuncs"`
+	DisabledFuncs       []string `json:"disabledFuncs"`
+	DisallowedFuncs     []string `json:"disallowedFuncs"`
+	UnresolvedTemplates []string `json:"unresolvedTemplates"`
+	RecursiveTemplates  []string `json:"recursiveTemplates"`
+}
+
+// templateSetReport 是 CheckTemplateSet 的输出，OK 表示所有文件都没有问题
+type templateSetReport struct {
+	OK    bool              `json:"ok"`
+	Files []templateSetFile `json:"files"`
+}
+
+// checkTemplateSet 在不执行模板的情况下检查一组模板文件，汇总每个文件的全部问题，而不是遇到第一个错误就停止：
+// 语法错误、未注册的函数、需要通过选项开启的函数（optInFuncs）、不在允许列表中的函数（allowedJson 为空或 null 时不检查），
+// 以及 template 和 include 引用了集合中不存在的模板。引用可以指向任意文件中的 define 块或文件名本身，
+// 只检查名称为字符串常量的 include。此外还会列出每个文件中经引用链可以回到自身的模板。
+func checkTemplateSet(namesJson string, sourcesJson string, allowedJson string) RenderResult {
+	var names, sources []string
+	if err := json.Unmarshal([]byte(namesJson), &names); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template names: %v", err),
+		}
+	}
+	if err := json.Unmarshal([]byte(sourcesJson), &sources); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template sources: %v", err),
+		}
+	}
+	if len(names) != len(sources) {
+		return RenderResult{
+			Error: fmt.Sprintf("got %d template names but %d sources", len(names), len(sources)),
+		}
+	}
+	var allowedSet map[string]bool
+	if strings.TrimSpace(allowedJson) != "" {
+		var allowed []string
+		if err := json.Unmarshal([]byte(allowedJson), &allowed); err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to parse allowed functions: %v", err),
+			}
+		}
+		if allowed != nil {
+			allowedSet = make(map[string]bool, len(allowed))
+			for _, name := range allowed {
+				allowedSet[name] = true
+			}
+		}
+	}
+
+	// 先解析全部文件，收集整个集合中可引用的模板名称，再逐个文件检查
+	registered := templateFuncs(&templateSet{}, renderOptions{})
+	defined := map[string]bool{}
+	// refs 为整个集合的引用图，同名模板的引用合并在一起
+	refs := map[string][]string{}
+	fileTrees := make([]map[string]*parse.Tree, len(names))
+	report := templateSetReport{OK: true, Files: make([]templateSetFile, len(names))}
+	for i, name := range names {
+		report.Files[i] = templateSetFile{
+			Name:                name,
+			UnknownFuncs:        []string{},
+			DisabledFuncs:       []string{},
+			DisallowedFuncs:     []string{},
+			UnresolvedTemplates: []string{},
+			RecursiveTemplates:  []string{},
+		}
+		defined[name] = true
+		trees, err := parseNamedTrees(name, sources[i], "", "")
+		if err != nil {
+			report.Files[i].ParseError = err.Error()
+			report.OK = false
+			continue
+		}
+		fileTrees[i] = trees
+		for treeName, tree := range trees {
+			defined[treeName] = true
+			refs[treeName] = append(refs[treeName], templateReferences(map[string]*parse.Tree{treeName: tree})...)
+		}
+	}
+
+	for i, trees := range fileTrees {
+		if trees == nil {
+			continue
+		}
+		file := &report.Files[i]
+		for _, fn := range usedFuncs(trees) {
+			if _, ok := optInFuncs[fn]; ok {
+				file.DisabledFuncs = append(file.DisabledFuncs, fn)
+			} else if _, ok := registered[fn]; !ok && !goBuiltinFuncs[fn] {
+				file.UnknownFuncs = append(file.UnknownFuncs, fn)
+			} else if allowedSet != nil && !allowedSet[fn] {
+				file.DisallowedFuncs = append(file.DisallowedFuncs, fn)
+			}
+		}
+		for _, ref := range templateReferences(trees) {
+			if !defined[ref] {
+				file.UnresolvedTemplates = append(file.UnresolvedTemplates, ref)
+			}
+		}
+		for treeName := range trees {
+			if reachesSelf(treeName, refs) {
+				file.RecursiveTemplates = append(file.RecursiveTemplates, treeName)
+			}
+		}
+		sort.Strings(file.RecursiveTemplates)
+		if len(file.UnknownFuncs) > 0 || len(file.DisabledFuncs) > 0 || len(file.DisallowedFuncs) > 0 || len(file.UnresolvedTemplates) > 0 {
+			report.OK = false
+		}
+	}
+
+	encoded, err := encodeJSON(report)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode result: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
+
+// templateReferences 返回语法树中 template 动作和 include 调用引用的模板名称，按名称排序且不重复。
+// includeOrEmpty 允许引用不存在的模板，名称不是字符串常量的 include 无法静态确定，两者都会被忽略。
+func templateReferences(trees map[string]*parse.Tree) []string {
+	seen := map[string]bool{}
+	for _, tree := range trees {
+		walkTree(tree.Root, func(node parse.Node) {
+			switch n := node.(type) {
+			case *parse.TemplateNode:
+				seen[n.Name] = true
+			case *parse.CommandNode:
+				if len(n.Args) < 2 {
+					return
+				}
+				ident, ok := n.Args[0].(*parse.IdentifierNode)
+				if !ok || ident.Ident != "include" {
+					return
+				}
+				if name, ok := n.Args[1].(*parse.StringNode); ok {
+					seen[name.Text] = true
+				}
+			}
+		})
+	}
+	refs := make([]string, 0, len(seen))
+	for name := range seen {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// reachesSelf 检查从模板 name 出发沿引用图 refs 能否回到 name 自身
+func reachesSelf(name string, refs map[string][]string) bool {
+	visited := map[string]bool{}
+	stack := append([]string(nil), refs[name]...)
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if next == name {
+			return true
+		}
+		if visited[next] {
+			continue
+		}
+		visited[next] = true
+		stack = append(stack, refs[next]...)
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 调用时间相关函数或执行来自数据的模板时，模板不是确定性的；模板按选项中的分隔符解析
+func TestCheckDeterministic(t *testing.T) {
+	custom := renderOptions{LeftDelim: "[[", RightDelim: "]]"}
+	tests := []struct {
+		name     string
+		template string
+		opts     renderOptions
+		want     determinism
+	}{
+		{"pure", `{{ .name | snakecase }}{{ next "row" }}`, renderOptions{}, determinism{Deterministic: true, Funcs: []string{}}},
+		{"timeAgo", `{{ timeAgo .ts }}`, renderOptions{}, determinism{Deterministic: false, Funcs: []string{"timeAgo"}}},
+		{"renderString", `{{ renderString .src . }}`, renderOptions{}, determinism{Deterministic: false, Funcs: []string{"renderString"}}},
+		{"inside define", `{{ define "x" }}{{ renderString .src . }}{{ timeAgo .ts }}{{ end }}`, renderOptions{}, determinism{Deterministic: false, Funcs: []string{"renderString", "timeAgo"}}},
+		{"repeated call", `{{ timeAgo .a }}{{ timeAgo .b }}`, renderOptions{}, determinism{Deterministic: false, Funcs: []string{"timeAgo"}}},
+		{"nested pipeline", `{{ printf "%s" (timeAgo .ts) }}`, renderOptions{}, determinism{Deterministic: false, Funcs: []string{"timeAgo"}}},
+		{"text only", `timeAgo`, renderOptions{}, determinism{Deterministic: true, Funcs: []string{}}},
+		{"custom delimiters", `[[ timeAgo .ts ]]`, custom, determinism{Deterministic: false, Funcs: []string{"timeAgo"}}},
+		{"braces as text", `{{ timeAgo }} [[ .name ]]`, custom, determinism{Deterministic: true, Funcs: []string{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkDeterministic(tt.template, tt.opts)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			var got determinism
+			if err := json.Unmarshal([]byte(result.Output), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 语法错误返回错误；使用自定义分隔符的模板按默认分隔符解析时同样可能出错
+func TestCheckDeterministicParseError(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     renderOptions
+	}{
+		{"default delimiters", `{{ if }}`, renderOptions{}},
+		{"custom delimiters", `[[ if ]]`, renderOptions{LeftDelim: "[[", RightDelim: "]]"}},
+		{"custom syntax with default delimiters", `[[ .a ]]{{ "[[" }}{{ end }}`, renderOptions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkDeterministic(tt.template, tt.opts)
+			if !strings.HasPrefix(result.Error, "Failed to parse template: ") {
+				t.Errorf("got %+v, want a parse error", result)
+			}
+		})
+	}
+}
+
+// checkTemplateSetFiles 检查 files（文件名与源码交替排列）并解码报告
+func checkTemplateSetFiles(t *testing.T, allowedJson string, files ...string) templateSetReport {
+	t.Helper()
+	var names, sources []string
+	for i := 0; i < len(files); i += 2 {
+		names = append(names, files[i])
+		sources = append(sources, files[i+1])
+	}
+	namesJson, _ := json.Marshal(names)
+	sourcesJson, _ := json.Marshal(sources)
+	result := checkTemplateSet(string(namesJson), string(sourcesJson), allowedJson)
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var report templateSetReport
+	if err := json.Unmarshal([]byte(result.Output), &report); err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+// fileReport 构造只设置了部分字段的 templateSetFile，其余列表为空
+func fileReport(name string, set func(f *templateSetFile)) templateSetFile {
+	f := templateSetFile{
+		Name:                name,
+		UnknownFuncs:        []string{},
+		DisabledFuncs:       []string{},
+		DisallowedFuncs:     []string{},
+		UnresolvedTemplates: []string{},
+		RecursiveTemplates:  []string{},
+	}
+	if set != nil {
+		set(&f)
+	}
+	return f
+}
+
+func TestCheckTemplateSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed string
+		files   []string
+		wantOK  bool
+		want    []templateSetFile
+	}{
+		{
+			"clean set",
+			"",
+			[]string{"page", `{{ template "header" . }}{{ include "footer" . }}`, "parts", `{{ define "header" }}h{{ end }}{{ define "footer" }}f{{ end }}`},
+			true,
+			[]templateSetFile{fileReport("page", nil), fileReport("parts", nil)},
+		},
+		{
+			"missing include and template",
+			"",
+			[]string{"page", `{{ include "nav" . }}{{ template "side" . }}{{ includeOrEmpty "opt" . }}{{ include .dynamic . }}`},
+			false,
+			[]templateSetFile{fileReport("page", func(f *templateSetFile) { f.UnresolvedTemplates = []string{"nav", "side"} })},
+		},
+		{
+			"file name is referenceable",
+			"",
+			[]string{"a", `{{ include "b" . }}`, "b", `b`},
+			true,
+			[]templateSetFile{fileReport("a", nil), fileReport("b", nil)},
+		},
+		{
+			"unknown and disallowed funcs",
+			`["upper"]`,
+			[]string{"page", `{{ nosuch . }}{{ snakecase .x }}`},
+			false,
+			[]templateSetFile{fileReport("page", func(f *templateSetFile) {
+				f.UnknownFuncs = []string{"nosuch"}
+				f.DisallowedFuncs = []string{"snakecase"}
+			})},
+		},
+		{
+			"renderString is disabled, not unknown",
+			"",
+			[]string{"page", `{{ renderString .src . }}`},
+			false,
+			[]templateSetFile{fileReport("page", func(f *templateSetFile) { f.DisabledFuncs = []string{"renderString"} })},
+		},
+		{
+			"self recursion is informational",
+			"",
+			[]string{"tree", `{{ define "node" }}{{ range .children }}{{ template "node" . }}{{ end }}{{ end }}{{ template "node" . }}`},
+			true,
+			[]templateSetFile{fileReport("tree", func(f *templateSetFile) { f.RecursiveTemplates = []string{"node"} })},
+		},
+		{
+			"cycle across files",
+			"",
+			[]string{"a", `{{ define "x" }}{{ include "y" . }}{{ end }}`, "b", `{{ define "y" }}{{ template "x" . }}{{ end }}{{ template "y" . }}`},
+			true,
+			[]templateSetFile{
+				fileReport("a", func(f *templateSetFile) { f.RecursiveTemplates = []string{"x"} }),
+				fileReport("b", func(f *templateSetFile) { f.RecursiveTemplates = []string{"y"} }),
+			},
+		},
+		{
+			"file includes itself",
+			"",
+			[]string{"loop", `{{ include "loop" . }}`},
+			true,
+			[]templateSetFile{fileReport("loop", func(f *templateSetFile) { f.RecursiveTemplates = []string{"loop"} })},
+		},
+		{
+			"parse error skips other checks",
+			"",
+			[]string{"bad", `{{ nosuch `},
+			false,
+			[]templateSetFile{fileReport("bad", func(f *templateSetFile) { f.ParseError = "template: bad:1: unclosed action" })},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := checkTemplateSetFiles(t, tt.allowed, tt.files...)
+			if report.OK != tt.wantOK {
+				t.Errorf("got ok %v, want %v", report.OK, tt.wantOK)
+			}
+			if !reflect.DeepEqual(report.Files, tt.want) {
+				t.Errorf("got files %+v, want %+v", report.Files, tt.want)
+			}
+		})
+	}
+}
+
+// 输入本身无效时返回整体错误
+func TestCheckTemplateSetInvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   string
+		sources string
+		allowed string
+		want    string
+	}{
+		{"bad names", `{`, `[]`, "", "Failed to parse template names: "},
+		{"bad sources", `[]`, `"x"`, "", "Failed to parse template sources: "},
+		{"length mismatch", `["a", "b"]`, `["a"]`, "", "got 2 template names but 1 sources"},
+		{"bad allowed", `["a"]`, `["a"]`, `{}`, "Failed to parse allowed functions: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkTemplateSet(tt.names, tt.sources, tt.allowed)
+			if !strings.HasPrefix(result.Error, tt.want) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.want)
+			}
+		})
+	}
+}
+
+// checkAllowedFuncs 按选项中的分隔符解析模板，动作内的函数和 define 块中的函数都会被检查
+func TestCheckAllowedFuncs(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		allowed  string
+		opts     renderOptions
+		wantErr  string
+	}{
+		{"allowed", `{{ .x | snakecase }}`, `["snakecase"]`, renderOptions{}, ""},
+		{"disallowed", `{{ .x | snakecase }}{{ printf "%d" 1 }}`, `["snakecase"]`, renderOptions{}, "template uses functions that are not allowed: printf"},
+		{"inside define", `{{ define "a" }}{{ nosuch }}{{ end }}`, `[]`, renderOptions{}, "template uses functions that are not allowed: nosuch"},
+		{"custom delimiters", `[[ .x | snakecase ]] {{ ignored }}`, `["snakecase"]`, renderOptions{LeftDelim: "[[", RightDelim: "]]"}, ""},
+		{"custom delimiters disallowed", `[[ nosuch .x ]]`, `["snakecase"]`, renderOptions{LeftDelim: "[[", RightDelim: "]]"}, "template uses functions that are not allowed: nosuch"},
+		{"default delimiters with custom syntax", `[[ nosuch .x ]]`, `[]`, renderOptions{}, ""},
+		{"bad allowed list", `x`, `{`, renderOptions{}, "Failed to parse allowed functions: "},
+		{"parse error", `[[ .x `, `[]`, renderOptions{LeftDelim: "[[", RightDelim: "]]"}, "Failed to parse template: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := checkAllowedFuncs(tt.template, tt.allowed, tt.opts)
+			if tt.wantErr == "" {
+				if result.Error != "" {
+					t.Errorf("unexpected error: %s", result.Error)
+				}
+				return
+			}
+			if !strings.HasPrefix(result.Error, tt.wantErr) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// checksumLabel 位于注释前缀之后、校验和之前，用于识别校验和所在的行
+const checksumLabel = " gotpl-checksum: sha256:"
+
+// checksumComments 为 embedChecksum 支持的注释前缀及其对应的结尾
+var checksumComments = map[string]string{
+	"#":    "",
+	"//":   "",
+	"<!--": " -->",
+}
+
+// checksumOutput 是 VerifyChecksum 的输出，Expected 为内容中记录的校验和，Actual 为重新计算的校验和
+type checksumOutput struct {
+	Valid    bool   `json:"valid"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// checkChecksumComment 校验 embedChecksum 选项的注释前缀
+func checkChecksumComment(prefix string) error {
+	if _, ok := checksumComments[prefix]; ok {
+		return nil
+	}
+	prefixes := make([]string, 0, len(checksumComments))
+	for p := range checksumComments {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	return fmt.Errorf("invalid embedChecksum option %q (expected one of %s)", prefix, strings.Join(prefixes, ", "))
+}
+
+// embedChecksum 在输出末尾追加一行注释，记录之前全部内容的 SHA-256，例如 "# gotpl-checksum: sha256:..."。
+// 不以换行符结尾的输出会先补上换行符（计入校验范围），注释行使用与 lineEnding 选项一致的换行符。
+func embedChecksum(output string, prefix string, lineEnding string) string {
+	newline := "\n"
+	if lineEnding == "crlf" {
+		newline = "\r\n"
+	}
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		output += newline
+	}
+	sum := sha256.Sum256([]byte(output))
+	return output + prefix + checksumLabel + hex.EncodeToString(sum[:]) + checksumComments[prefix] + newline
+}
+
+// verifyChecksum 检查 embedChecksum 生成的内容：最后一行必须是使用 prefix 的校验和注释，
+// 其记录的校验和与之前全部内容的 SHA-256 相同时 valid 为 true。找不到校验和注释时返回错误。
+func verifyChecksum(content string, prefix string) RenderResult {
+	if err := checkChecksumComment(prefix); err != nil {
+		return RenderResult{Error: err.Error()}
+	}
+
+	rest := strings.TrimSuffix(strings.TrimSuffix(content, "\n"), "\r")
+	lineStart := strings.LastIndex(rest, "\n") + 1
+	line := rest[lineStart:]
+	start := prefix + checksumLabel
+	end := checksumComments[prefix]
+	if !strings.HasPrefix(line, start) || !strings.HasSuffix(line, end) || len(line) < len(start)+len(end) {
+		return RenderResult{
+			Error: fmt.Sprintf("no checksum comment starting with %q found on the last line", start),
+		}
+	}
+
+	sum := sha256.Sum256([]byte(content[:lineStart]))
+	result := checksumOutput{
+		Expected: line[len(start) : len(line)-len(end)],
+		Actual:   hex.EncodeToString(sum[:]),
+	}
+	result.Valid = result.Expected == result.Actual
+
+	encoded, err := encodeJSON(result)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode checksum result: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
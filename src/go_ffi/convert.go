@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// toInt 将模板中出现的各种数值类型转换为 int。
+// JSON 解码出来的数字是 float64，模板字面量是 int，这里统一处理。
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int8:
+		return int(n), nil
+	case int16:
+		return int(n), nil
+	case int32:
+		return int(n), nil
+	case int64:
+		return int(n), nil
+	case uint:
+		return int(n), nil
+	case uint8:
+		return int(n), nil
+	case uint16:
+		return int(n), nil
+	case uint32:
+		return int(n), nil
+	case uint64:
+		return int(n), nil
+	case float32:
+		return toInt(float64(n))
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("expected an integer, got %v", n)
+		}
+		return int(n), nil
+	case json.Number:
+		i, err := strconv.Atoi(n.String())
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", n.String())
+		}
+		return i, nil
+	case string:
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", n)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", v)
+	}
+}
+
+// toStringSlice 将任意列表按 toString 转换为 []string，nil 元素会被跳过。
+// 非列表的值会被当作只有一个元素的列表。
+func toStringSlice(v interface{}) []string {
+	switch s := v.(type) {
+	case nil:
+		return []string{}
+	case []string:
+		return s
+	case []interface{}:
+		out := make([]string, 0, len(s))
+		for _, e := range s {
+			if e == nil {
+				continue
+			}
+			out = append(out, toString(e))
+		}
+		return out
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return []string{toString(v)}
+	}
+	out := make([]string, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		e := val.Index(i).Interface()
+		if e == nil {
+			continue
+		}
+		out = append(out, toString(e))
+	}
+	return out
+}
+
+// number 是数学辅助函数使用的统一数值表示
+type number struct {
+	i     int64
+	f     float64
+	isInt bool
+}
+
+func (n number) float() float64 {
+	if n.isInt {
+		return float64(n.i)
+	}
+	return n.f
+}
+
+// value 返回适合在模板中输出的值：整数返回 int64，否则返回 float64
+func (n number) value() interface{} {
+	if n.isInt {
+		return n.i
+	}
+	return n.f
+}
+
+// toNumber 将模板中的数值转换为 number。
+// 没有小数部分的 float64（JSON 解码出的整数就是这种形式）视为整数。
+func toNumber(v interface{}) (number, error) {
+	switch n := v.(type) {
+	case int:
+		return number{i: int64(n), isInt: true}, nil
+	case int8:
+		return number{i: int64(n), isInt: true}, nil
+	case int16:
+		return number{i: int64(n), isInt: true}, nil
+	case int32:
+		return number{i: int64(n), isInt: true}, nil
+	case int64:
+		return number{i: n, isInt: true}, nil
+	case uint:
+		return number{i: int64(n), isInt: true}, nil
+	case uint8:
+		return number{i: int64(n), isInt: true}, nil
+	case uint16:
+		return number{i: int64(n), isInt: true}, nil
+	case uint32:
+		return number{i: int64(n), isInt: true}, nil
+	case uint64:
+		return number{i: int64(n), isInt: true}, nil
+	case float32:
+		return toNumber(float64(n))
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+			return number{i: int64(n), isInt: true}, nil
+		}
+		return number{f: n}, nil
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return number{i: i, isInt: true}, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return number{}, fmt.Errorf("expected a number, got %q", n.String())
+		}
+		return toNumber(f)
+	default:
+		return number{}, fmt.Errorf("expected a number, got %T", v)
+	}
+}
+
+// toNumbers 批量转换数值
+func toNumbers(values []interface{}) ([]number, error) {
+	out := make([]number, len(values))
+	for i, v := range values {
+		n, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+// toList 将任意切片或数组转换为 []interface{}，nil 返回空列表。
+// 其他类型的值会返回错误。
+func toList(v interface{}) ([]interface{}, error) {
+	switch l := v.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		return l, nil
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	out := make([]interface{}, val.Len())
+	for i := range out {
+		out[i] = val.Index(i).Interface()
+	}
+	return out, nil
+}
+
+// toString 将标量值格式化为字符串。
+// float64 使用不带指数的最短表示，避免 JSON 中的 1000000 被输出为 1e+06。
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(s), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// encodeJSON 将结果编码为 JSON 字符串，不对 <、>、& 做 HTML 转义，
+// 因为这些结果是交给调用方程序处理的，而不是嵌入 HTML 页面。
+func encodeJSON(v interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
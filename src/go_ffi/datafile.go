@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// errorReader 记录底层读取时发生的第一个错误（io.EOF 除外），
+// 用于在解码失败时区分文件读取错误与数据格式错误
+type errorReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errorReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// resolveDataPath 将数据文件路径解析为真实路径，并确认它位于 baseDir 之内。
+// 相对路径相对于 baseDir，符号链接会先被解析，因此无法通过链接读取目录外的文件。
+func resolveDataPath(baseDir string, path string) (string, error) {
+	if baseDir == "" {
+		return "", errors.New("the dataBaseDir option must be set to read data files")
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	if base, err = filepath.EvalSymlinks(base); err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is outside the allowed base directory %q", path, baseDir)
+	}
+	return resolved, nil
+}
+
+// decodeDataStream 按格式（json、yaml 或 toml）从 r 中解码顶层为对象的数据
+func decodeDataStream(r io.Reader, format string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+	switch format {
+	case "json":
+		dec := json.NewDecoder(r)
+		if err := dec.Decode(&data); err != nil {
+			return nil, err
+		}
+		if err := dec.Decode(&struct{}{}); err != io.EOF {
+			return nil, errors.New("unexpected data after the top-level JSON value")
+		}
+	case "yaml":
+		var v interface{}
+		if err := yaml.NewDecoder(r).Decode(&v); err != nil && err != io.EOF {
+			return nil, err
+		}
+		if v == nil {
+			return data, nil
+		}
+		m, ok := normalizeYAML(v).(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("top-level YAML value must be a mapping, got %T", v)
+		}
+		data = m
+	case "toml":
+		if _, err := toml.NewDecoder(r).Decode(&data); err != nil {
+			return nil, err
+		}
+		data = normalizeTOML(data).(map[string]interface{})
+	}
+	return data, nil
+}
+
+// normalizeTOML 将表数组（[]map[string]interface{}）转换为 []interface{}，
+// 使其可以和 JSON 数据一样用于列表相关的函数
+func normalizeTOML(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, e := range val {
+			val[k] = normalizeTOML(e)
+		}
+		return val
+	case []map[string]interface{}:
+		list := make([]interface{}, len(val))
+		for i, e := range val {
+			list[i] = normalizeTOML(e)
+		}
+		return list
+	case []interface{}:
+		for i, e := range val {
+			val[i] = normalizeTOML(e)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// renderDataFile 从位于 opts.DataBaseDir 之内的数据文件流式读取数据并渲染模板，
+// 数据不需要经过 C 字符串传递。读取文件失败与解码失败会分别报告。
+func renderDataFile(templateContent string, path string, format string, opts renderOptions) RenderResult {
+	switch format {
+	case "json", "yaml", "toml":
+	default:
+		return RenderResult{
+			Error: fmt.Sprintf("Unsupported data file format %q (expected json, yaml or toml)", format),
+		}
+	}
+
+	resolved, err := resolveDataPath(opts.DataBaseDir, path)
+	if err != nil {
+		logf(logError, "failed to read data file: %v", err)
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to read data file: %v", err),
+		}
+	}
+	f, err := os.Open(resolved)
+	if err != nil {
+		logf(logError, "failed to read data file: %v", err)
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to read data file: %v", err),
+		}
+	}
+	defer f.Close()
+
+	r := &errorReader{r: f}
+	data, err := decodeDataStream(r, format)
+	if r.err != nil {
+		logf(logError, "failed to read data file: %v", r.err)
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to read data file: %v", r.err),
+		}
+	}
+	if err != nil {
+		logf(logError, "failed to decode %s data file: %v", format, err)
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to decode %s data file: %v", format, err),
+		}
+	}
+
+	return executeGoTemplate(templateContent, prepareData(data, opts), opts)
+}
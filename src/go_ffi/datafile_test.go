@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDataFiles 在临时目录中写入测试用的数据文件，返回该目录
+func writeDataFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// 三种格式解码后的数据在模板中的用法相同，YAML 的映射和 TOML 的表数组都可以用 range 遍历
+func TestRenderDataFile(t *testing.T) {
+	dir := writeDataFiles(t, map[string]string{
+		"data.json":       `{"name": "x", "items": [{"id": 1}, {"id": 2}]}`,
+		"data.yaml":       "name: x\nitems:\n  - id: 1\n  - id: 2\n",
+		"data.toml":       "name = \"x\"\n[[items]]\nid = 1\n[[items]]\nid = 2\n",
+		"empty.yaml":      "",
+		"list.yaml":       "- 1\n- 2\n",
+		"trailing.json":   `{"name": "x"} {}`,
+		"broken.json":     `{"name":`,
+		"broken.toml":     `name = `,
+		"sub/nested.json": `{"name": "nested"}`,
+	})
+	const template = `{{ .name }}:{{ range .items }}[{{ .id }}]{{ end }}`
+	tests := []struct {
+		name    string
+		path    string
+		format  string
+		want    string
+		wantErr string
+	}{
+		{"json", "data.json", "json", "x:[1][2]", ""},
+		{"yaml", "data.yaml", "yaml", "x:[1][2]", ""},
+		{"toml", "data.toml", "toml", "x:[1][2]", ""},
+		{"empty yaml", "empty.yaml", "yaml", "<no value>:", ""},
+		{"subdirectory", "sub/nested.json", "json", "nested:", ""},
+		{"absolute path inside", filepath.Join(dir, "data.json"), "json", "x:[1][2]", ""},
+		{"yaml list", "list.yaml", "yaml", "", "Failed to decode yaml data file: top-level YAML value must be a mapping"},
+		{"trailing json", "trailing.json", "json", "", "Failed to decode json data file: unexpected data after the top-level JSON value"},
+		{"broken json", "broken.json", "json", "", "Failed to decode json data file: "},
+		{"broken toml", "broken.toml", "toml", "", "Failed to decode toml data file: "},
+		{"wrong format", "data.yaml", "json", "", "Failed to decode json data file: "},
+		{"missing file", "missing.json", "json", "", "Failed to read data file: "},
+		{"directory", "sub", "json", "", "Failed to read data file: "},
+		{"unsupported format", "data.json", "xml", "", `Unsupported data file format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderDataFile(template, tt.path, tt.format, renderOptions{DataBaseDir: dir})
+			if tt.wantErr != "" {
+				if !strings.HasPrefix(result.Error, tt.wantErr) {
+					t.Errorf("got error %q, want prefix %q", result.Error, tt.wantErr)
+				}
+				return
+			}
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// 相对路径相对于 baseDir 解析，baseDir 之外的路径和指向外部的符号链接都会被拒绝
+func TestResolveDataPath(t *testing.T) {
+	outside := writeDataFiles(t, map[string]string{"secret.json": `{}`})
+	dir := writeDataFiles(t, map[string]string{"data.json": `{}`, "sub/data.json": `{}`})
+	if err := os.Symlink(filepath.Join(outside, "secret.json"), filepath.Join(dir, "link.json")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "sub", "data.json"), filepath.Join(dir, "inner.json")); err != nil {
+		t.Fatal(err)
+	}
+	base, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		baseDir string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"relative", dir, "data.json", filepath.Join(base, "data.json"), ""},
+		{"cleaned", dir, "sub/../data.json", filepath.Join(base, "data.json"), ""},
+		{"absolute", dir, filepath.Join(dir, "sub", "data.json"), filepath.Join(base, "sub", "data.json"), ""},
+		{"inner symlink", dir, "inner.json", filepath.Join(base, "sub", "data.json"), ""},
+		{"base dir relative to cwd", mustRel(t, dir), "data.json", filepath.Join(base, "data.json"), ""},
+		{"parent", dir, filepath.Join("..", filepath.Base(outside), "secret.json"), "", "outside the allowed base directory"},
+		{"absolute outside", dir, filepath.Join(outside, "secret.json"), "", "outside the allowed base directory"},
+		{"outer symlink", dir, "link.json", "", "outside the allowed base directory"},
+		{"missing", dir, "missing.json", "", "no such file or directory"},
+		{"no base dir", "", "data.json", "", "the dataBaseDir option must be set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDataPath(tt.baseDir, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// mustRel 返回 path 相对于当前工作目录的路径
+func mustRel(t *testing.T, path string) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rel
+}
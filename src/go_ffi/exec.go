@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sort"
+	"sync/atomic"
+	texttemplate "text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+)
+
+// goTemplate 是 html/template 与 text/template 共有的执行接口
+type goTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+	ExecuteTemplate(w io.Writer, name string, data interface{}) error
+}
+
+// rootTemplateName 是根模板的名称，与 parseGoTemplate 中使用的名称一致
+const rootTemplateName = "goTemplate"
+
+// parseGoTemplate 按选项解析模板，escapeHtml 决定使用 html/template 还是 text/template。
+// 返回的 kind 用于错误信息（"HTML" 或 "Text"）。只执行一次的调用方使用它即可，
+// 需要多次执行同一份解析结果的调用方使用 parseTemplateSet。
+func parseGoTemplate(templateContent string, opts renderOptions) (goTemplate, string, error) {
+	set, kind, err := parseTemplateSet(templateContent, opts)
+	return set.tmpl, kind, err
+}
+
+// parseTemplateSet 与 parseGoTemplate 相同，但返回整个模板集合，
+// 需要多次执行同一份解析结果时通过 set.renderOnce 执行，每次执行前会清空上一次执行留下的状态
+func parseTemplateSet(templateContent string, opts renderOptions) (*templateSet, string, error) {
+	kind := "Text"
+	if opts.EscapeHtml {
+		kind = "HTML"
+	}
+
+	// 依赖模板集合或渲染选项的函数需要在解析前注册，模板在解析完成后再绑定
+	set := &templateSet{escapeHtml: opts.EscapeHtml}
+	funcs := templateFuncs(set, opts)
+
+	if opts.StrictFuncs {
+		if err := checkDefinedFuncs(templateContent, opts, funcs); err != nil {
+			return set, kind, err
+		}
+	}
+
+	tmpl, err := parseSource(rootTemplateName, templateContent, opts, funcs)
+	set.tmpl = tmpl
+	set.opts = opts
+	set.funcMap = funcs
+	set.source = templateContent
+	return set, kind, err
+}
+
+// templateFuncs 返回一次渲染中注册到模板上的全部函数：静态的 builtinFuncs，
+// 以及绑定到模板集合 set、当前时间、计数器状态和渲染选项的函数。计数器的 reset 保存在 set 中。
+func templateFuncs(set *templateSet, opts renderOptions) map[string]interface{} {
+	counters, resetCounters := counterFuncs()
+	set.resetCounters = resetCounters
+	funcs := builtinFuncs()
+	for _, extra := range []map[string]interface{}{set.funcs(opts), timeFuncs(opts.now), counters, constFuncs(opts.Constants)} {
+		for name, fn := range extra {
+			funcs[name] = fn
+		}
+	}
+	if opts.MissingPlaceholder != nil {
+		funcs[missingPlaceholderFunc] = placeholderFunc(*opts.MissingPlaceholder)
+	}
+	return funcs
+}
+
+// parseSource 使用给定的函数解析模板源码，并在解析成功后改写每棵语法树，
+// escapeHtml 决定使用 html/template 还是 text/template
+func parseSource(name string, templateContent string, opts renderOptions, funcs map[string]interface{}) (goTemplate, error) {
+	missingKey := opts.missingKeyOption()
+	if opts.EscapeHtml {
+		// 使用 html/template 确保安全性，防止 XSS
+		t := htmltemplate.New(name).Option(missingKey).Delims(opts.LeftDelim, opts.RightDelim).Funcs(funcs)
+		t, err := t.Parse(templateContent)
+		if err != nil {
+			return t, err
+		}
+		// 在首次执行转义之前改写语法树，占位符同样会被转义
+		for _, tt := range t.Templates() {
+			rewriteTree(tt.Tree, templateContent, opts)
+		}
+		return t, nil
+	}
+
+	// 使用 text/template 渲染，不进行 HTML 转义
+	t := texttemplate.New(name).Option(missingKey).Delims(opts.LeftDelim, opts.RightDelim).Funcs(funcs)
+	t, err := t.Parse(templateContent)
+	if err != nil {
+		return t, err
+	}
+	for _, tt := range t.Templates() {
+		rewriteTree(tt.Tree, templateContent, opts)
+	}
+	return t, nil
+}
+
+var errExecutionTimeout = errors.New("template execution timed out")
+
+// errPreviewComplete 表示输出已经超过 PreviewBytes，用于提前停止执行，不会作为错误返回给调用方
+var errPreviewComplete = errors.New("preview limit reached")
+
+// previewMarker 追加在被 PreviewBytes 截断的输出末尾
+const previewMarker = "... (truncated)"
+
+// outputLimitError 表示输出超出了 MaxOutputBytes 限制
+type outputLimitError struct {
+	limit int64
+}
+
+func (e outputLimitError) Error() string {
+	return fmt.Sprintf("rendered output exceeds %d bytes", e.limit)
+}
+
+// templateFailurePrefix 是 fail 和 failIf 中止渲染时 RenderResult.Error 的固定前缀，
+// 调用方据此区分模板作者主动触发的失败与引擎错误
+const templateFailurePrefix = "Template failure: "
+
+// templateFailure 是 fail 和 failIf 返回的错误，Go 模板以 %w 包装辅助函数的错误，因此可以用 errors.As 识别
+type templateFailure struct {
+	msg string
+}
+
+func (e templateFailure) Error() string {
+	return e.msg
+}
+
+// executionError 将模板执行错误转换为渲染结果，由 fail 触发的错误只保留作者的消息并加上 templateFailurePrefix
+func executionError(kind string, err error) RenderResult {
+	var failure templateFailure
+	if errors.As(err, &failure) {
+		return RenderResult{
+			Error: templateFailurePrefix + failure.msg,
+		}
+	}
+	return RenderResult{
+		Error: fmt.Sprintf("Failed to execute %s template: %v", kind, err),
+	}
+}
+
+// outputWriter 在写入时检查输出大小限制和超时标记，
+// 写入返回的错误会让模板立即停止执行。
+type outputWriter struct {
+	buf      *bytes.Buffer
+	limit    int64
+	preview  int
+	timedOut int32
+}
+
+func (w *outputWriter) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(&w.timedOut) != 0 {
+		return 0, errExecutionTimeout
+	}
+	if w.preview > 0 && w.buf.Len()+len(p) > w.preview {
+		// 写入完整的内容，以便截断时判断第 preview 个字节是否位于字符中间
+		w.buf.Write(p)
+		return 0, errPreviewComplete
+	}
+	if w.limit > 0 && int64(w.buf.Len()+len(p)) > w.limit {
+		return 0, outputLimitError{limit: w.limit}
+	}
+	return w.buf.Write(p)
+}
+
+// truncatePreview 将 buf 截断到不超过 preview 个字节，且不会截断多字节字符
+func truncatePreview(buf *bytes.Buffer, preview int) {
+	data := buf.Bytes()
+	cut := preview
+	for cut > 0 && !utf8.RuneStart(data[cut]) {
+		cut--
+	}
+	buf.Truncate(cut)
+}
+
+// executeWithLimits 调用 execute 执行模板，并应用超时与输出大小限制。
+// Go 模板无法从外部中断，超时后会立即返回错误，执行中的 goroutine
+// 会在下一次写入输出时停止；不产生输出的死循环将继续占用该 goroutine。
+func executeWithLimits(opts renderOptions, execute func(w io.Writer) error) (*bytes.Buffer, error) {
+	w := &outputWriter{buf: &bytes.Buffer{}, limit: opts.MaxOutputBytes, preview: opts.PreviewBytes}
+	if size := int64(opts.ExpectedSize); size > 0 {
+		// 预分配的大小不必超过输出上限
+		if opts.MaxOutputBytes > 0 && size > opts.MaxOutputBytes {
+			size = opts.MaxOutputBytes
+		}
+		w.buf.Grow(int(size))
+	}
+	if opts.TimeoutMs <= 0 {
+		return w.buf, execute(w)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- execute(w)
+	}()
+
+	timer := time.NewTimer(time.Duration(opts.TimeoutMs) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return w.buf, err
+	case <-timer.C:
+		atomic.StoreInt32(&w.timedOut, 1)
+		return nil, fmt.Errorf("%w after %dms", errExecutionTimeout, opts.TimeoutMs)
+	}
+}
+
+// definedTemplates 返回模板集合中所有内容非空的模板名称，按名称排序。
+// 只包含空白文本的模板（例如只有 define 块的根模板）会被跳过。
+func definedTemplates(tmpl goTemplate) []string {
+	var names []string
+	for name, tree := range templateTrees(tmpl) {
+		if tree.Root != nil && !isBlankList(tree.Root) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateTrees 返回模板集合中每个已解析模板的语法树，键为模板名称
+func templateTrees(tmpl goTemplate) map[string]*parse.Tree {
+	trees := map[string]*parse.Tree{}
+	switch t := tmpl.(type) {
+	case *htmltemplate.Template:
+		for _, sub := range t.Templates() {
+			if sub.Tree != nil {
+				trees[sub.Name()] = sub.Tree
+			}
+		}
+	case *texttemplate.Template:
+		for _, sub := range t.Templates() {
+			if sub.Tree != nil {
+				trees[sub.Name()] = sub.Tree
+			}
+		}
+	}
+	return trees
+}
+
+// addTemplateFuncs 在解析之后、执行之前为模板集合追加函数
+func addTemplateFuncs(tmpl goTemplate, funcs map[string]interface{}) {
+	switch t := tmpl.(type) {
+	case *htmltemplate.Template:
+		t.Funcs(funcs)
+	case *texttemplate.Template:
+		t.Funcs(funcs)
+	}
+}
+
+// hasTemplate 判断模板集合中是否定义了指定名称的模板
+func hasTemplate(tmpl goTemplate, name string) bool {
+	switch t := tmpl.(type) {
+	case *htmltemplate.Template:
+		return t.Lookup(name) != nil
+	case *texttemplate.Template:
+		return t.Lookup(name) != nil
+	}
+	return false
+}
+
+// isBlankList 判断节点列表是否只包含空白文本
+func isBlankList(list *parse.ListNode) bool {
+	for _, node := range list.Nodes {
+		text, ok := node.(*parse.TextNode)
+		if !ok || len(bytes.TrimSpace(text.Text)) > 0 {
+			return false
+		}
+	}
+	return true
+}
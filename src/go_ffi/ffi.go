@@ -3,22 +3,68 @@ package main
 /*
 #include <stdlib.h> // For C.free
 #include <stdbool.h> // For C.bool (更标准)
+#include <string.h> // For C.strlen
 
 typedef struct RenderResult {
     char* output;
     char* error;
 } RenderResult;
 
-extern RenderResult RenderTemplate(char* templateContent, char* jsonData, bool escapeHtml, bool useMissingKeyZero);
+typedef struct BytesResult {
+    char* data;
+    size_t length;
+    char* error;
+} BytesResult;
+
+typedef struct LinesResult {
+    char** lines;
+    int count;
+    char* error;
+} LinesResult;
+
+typedef bool (*batch_write_callback_t)(void* userData, int index, const char* output, const char* error);
+
+// Go 无法直接调用 C 函数指针，需要通过一个 C 函数转发
+static inline bool callBatchWriteCallback(batch_write_callback_t cb, void* userData, int index, const char* output, const char* error) {
+    return cb(userData, index, output, error);
+}
+
+extern RenderResult RenderTemplate(char* templateContent, char* jsonData, bool escapeHtml, bool useMissingKeyZero, bool failOnEmptyOutput, bool lineNumbers, char* rootKey, char* minifyType);
+extern RenderResult RenderWithOptions(char* templateContent, char* jsonData, char* optionsJson);
+extern RenderResult RenderAll(char* templateContent, char* jsonData, char* optionsJson);
+extern BytesResult RenderBytes(char* templateContent, char* jsonData, char* optionsJson);
+extern LinesResult RenderLines(char* templateContent, char* jsonData, char* optionsJson);
+extern RenderResult CheckAllowedFuncs(char* templateContent, char* allowedJson);
+extern RenderResult CheckAllowedFuncsWithOptions(char* templateContent, char* allowedJson, char* optionsJson);
+extern RenderResult CheckTemplateSet(char* namesJson, char* sourcesJson, char* allowedFuncsJson);
+extern RenderResult IsTemplateDeterministic(char* templateContent);
+extern RenderResult IsTemplateDeterministicWithOptions(char* templateContent, char* optionsJson);
+extern RenderResult VerifyChecksum(char* content, char* commentPrefix);
+extern RenderResult RenderAndAssert(char* templateContent, char* jsonData, char* expected, char* optionsJson, bool normalizeWhitespace);
+extern RenderResult RenderFromDataFile(char* templateContent, char* dataFilePath, char* format, char* optionsJson);
+extern RenderResult RenderBatch(char* templateContent, char* dataJsonArray, char* optionsJson, batch_write_callback_t writeCallback, void* userData);
+extern RenderResult RenderMatrix(char* templateContent, char* matrixJson, char* optionsJson);
+extern RenderResult RenderWithDataChain(char* templateContent, char* dataJsonArray, char* optionsJson);
+extern RenderResult RenderWithSourceMap(char* templateContent, char* jsonData, char* optionsJson);
+extern RenderResult GenerateSampleData(char* templateContent);
+extern RenderResult BeginRenderSession(char* templateContent, char* jsonData, char* optionsJson);
+extern RenderResult ProvideKey(long long session, char* pathJson, char* valueJson);
+extern void EndRenderSession(long long session);
+extern RenderResult RenderToSharedMem(char* templateContent, char* jsonData, char* optionsJson);
+extern RenderResult ReleaseSharedMem(char* name);
+extern RenderResult RegisterProtoDescriptors(char* descriptorSet, int length);
+extern RenderResult RenderTemplateProto(char* templateContent, char* protoBytes, int length, char* messageType, char* optionsJson);
+extern RenderResult RenderWithFallback(char* mainSource, char* fallbackSource, char* jsonData, bool escapeHtml, bool useMissingKeyZero, bool failOnEmptyOutput, bool lineNumbers, char* rootKey, char* minifyType);
 */
 import "C"
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	htmltemplate "html/template" // 为 html/template 起别名
-	texttemplate "text/template" // 为 text/template 起别名
-	"unsafe"                     // 用于C语言指针操作
+	"io"
+	"strings"
+	"unsafe" // 用于C语言指针操作
 )
 
 type RenderResult struct {
@@ -26,78 +72,624 @@ type RenderResult struct {
 	Error  string
 }
 
-// renderGoTemplate 是实际的模板渲染逻辑
-// 增加了 escapeHtml 和 useMissingKeyZero 参数
-func renderGoTemplate(templateContent string, jsonData string, escapeHtml bool, useMissingKeyZero bool) RenderResult {
-	var data map[string]interface{}
-	err := json.Unmarshal([]byte(jsonData), &data)
+// renderGoTemplate 是实际的模板渲染逻辑，各项选项的含义见 renderOptions
+func renderGoTemplate(templateContent string, jsonData string, opts renderOptions) RenderResult {
+	data, err := decodeTemplateData(jsonData, opts)
 	if err != nil {
+		logf(logError, "failed to unmarshal JSON data: %v", err)
 		return RenderResult{
 			Error: fmt.Sprintf("Failed to unmarshal JSON data: %v", err),
 		}
 	}
 
-	var buf bytes.Buffer
+	return executeGoTemplate(templateContent, data, opts)
+}
 
-	tmplOptions := "missingkey="
-	if useMissingKeyZero {
-		tmplOptions += "zero"
-	} else {
-		tmplOptions += "default"
+// decodeTemplateData 解码 JSON 数据，并按 prepareData 处理
+func decodeTemplateData(jsonData string, opts renderOptions) (interface{}, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(jsonData), &data); err != nil {
+		return nil, err
 	}
+	return prepareData(data, opts), nil
+}
 
-	if escapeHtml {
-		// 使用 html/template 确保安全性，防止 XSS
-		// 根据 tmplOptions 创建模板
-		tmpl := htmltemplate.New("goTemplate").Option(tmplOptions)
-		tmpl, err = tmpl.Parse(templateContent)
-		if err != nil {
-			return RenderResult{
-				Error: fmt.Sprintf("Failed to parse HTML template: %v", err),
-			}
-		}
-		err = tmpl.Execute(&buf, data)
-		if err != nil {
-			return RenderResult{
-				Error: fmt.Sprintf("Failed to execute HTML template: %v", err),
-			}
+// prepareData 对解码后的数据应用 opts.Preprocess 中的变换，
+// opts.RootKey 非空时将结果包装在该键下
+func prepareData(data map[string]interface{}, opts renderOptions) interface{} {
+	transformed := applyTransforms(data, opts.Preprocess)
+	if opts.RootKey != "" {
+		return map[string]interface{}{opts.RootKey: transformed}
+	}
+	return transformed
+}
+
+// executeGoTemplate 使用已经解码的数据解析并执行模板
+func executeGoTemplate(templateContent string, data interface{}, opts renderOptions) RenderResult {
+	logf(logDebug, "parsing template (%d bytes)", len(templateContent))
+	tmpl, kind, err := parseGoTemplate(templateContent, opts)
+	if err != nil {
+		logf(logError, "failed to parse %s template: %v", kind, err)
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse %s template: %v", kind, err),
 		}
+	}
+
+	return renderParsed(tmpl, kind, "", data, opts)
+}
+
+// renderParsed 执行已解析的模板并对输出做后处理。
+// name 为空时执行根模板，否则执行模板集合中对应名称的模板。
+func renderParsed(tmpl goTemplate, kind string, name string, data interface{}, opts renderOptions) RenderResult {
+	if name == "" {
+		logf(logDebug, "executing %s template", kind)
 	} else {
-		// 使用 text/template 渲染，不进行 HTML 转义
-		// 根据 tmplOptions 创建模板
-		tmpl := texttemplate.New("goTemplate").Option(tmplOptions)
-		tmpl, err = tmpl.Parse(templateContent)
+		logf(logDebug, "executing %s template %q", kind, name)
+	}
+	buf, err := executeWithLimits(opts, func(w io.Writer) error {
+		if name == "" {
+			return tmpl.Execute(w, data)
+		}
+		return tmpl.ExecuteTemplate(w, name, data)
+	})
+	// 预览在超出 PreviewBytes 时提前停止执行，按成功处理，截断标记在其他后处理之后追加
+	truncated := errors.Is(err, errPreviewComplete)
+	if truncated {
+		truncatePreview(buf, opts.PreviewBytes)
+		err = nil
+	}
+	if err != nil {
+		var limitErr outputLimitError
+		if errors.Is(err, errExecutionTimeout) || errors.As(err, &limitErr) {
+			logf(logWarn, "render limit hit: %v", err)
+		} else {
+			logf(logError, "failed to execute %s template: %v", kind, err)
+		}
+		return executionError(kind, err)
+	}
+
+	// 渲染成功但没有产生任何输出，通常意味着入口或条件写错了
+	if opts.FailOnEmptyOutput && buf.Len() == 0 {
+		logf(logError, "rendered output was empty")
+		return RenderResult{
+			Error: "rendered output was empty",
+		}
+	}
+
+	output := buf.String()
+	if opts.Minify != "" {
+		// 压缩失败单独报告，便于与模板渲染错误区分
+		output, err = minifyOutput(output, opts.Minify)
 		if err != nil {
+			logf(logError, "failed to minify output: %v", err)
 			return RenderResult{
-				Error: fmt.Sprintf("Failed to parse Text template: %v", err),
+				Error: fmt.Sprintf("Failed to minify output: %v", err),
 			}
 		}
-		err = tmpl.Execute(&buf, data)
+	}
+	if opts.LineEnding == "lf" || opts.LineEnding == "crlf" {
+		// 在加行号之前统一换行符，这样单独的 CR 也会被视为换行；行号只在 LF 处插入，不会破坏 CRLF
+		output = normalizeLineEndings(output, opts.LineEnding)
+	}
+	if len(opts.PostFilters) > 0 {
+		output, err = applyOutputFilters(output, opts.PostFilters)
 		if err != nil {
+			logf(logError, "failed to apply post filters: %v", err)
 			return RenderResult{
-				Error: fmt.Sprintf("Failed to execute Text template: %v", err),
+				Error: fmt.Sprintf("Failed to apply post filters: %v", err),
 			}
 		}
 	}
+	if opts.LineNumbers {
+		output = numberLines(output)
+	}
+	if truncated {
+		output += previewMarker
+	}
+	if opts.EmbedChecksum != "" {
+		// 校验和覆盖全部后处理的结果，因此最后追加
+		output = embedChecksum(output, opts.EmbedChecksum, opts.LineEnding)
+	}
+
+	logf(logInfo, "rendered %d bytes", len(output))
 
 	return RenderResult{
-		Output: buf.String(),
+		Output: output,
 		Error:  "",
 	}
 }
 
+// positionalOptions 将早期导出函数的位置参数转换为 renderOptions
+func positionalOptions(escapeHtml bool, useMissingKeyZero bool, failOnEmptyOutput bool, lineNumbers bool, rootKey string, minifyType string) renderOptions {
+	opts := renderOptions{
+		EscapeHtml:        escapeHtml,
+		FailOnEmptyOutput: failOnEmptyOutput,
+		LineNumbers:       lineNumbers,
+		RootKey:           rootKey,
+		Minify:            minifyType,
+	}
+	if useMissingKeyZero {
+		opts.MissingKey = "zero"
+	}
+	return opts
+}
+
 // RenderTemplate 是暴露给 C 的函数。
-// 增加了 cEscapeHtml 和 cUseMissingKeyZero 参数。
+// 增加了 cEscapeHtml、cUseMissingKeyZero、cFailOnEmptyOutput、cLineNumbers、cRootKey 和 cMinifyType 参数。
+// 新的选项只会加入 RenderWithOptions，本函数的签名保持不变。
 //
 //export RenderTemplate
-func RenderTemplate(cTemplateContent *C.char, cJsonData *C.char, cEscapeHtml C._Bool, cUseMissingKeyZero C._Bool) C.RenderResult {
+func RenderTemplate(cTemplateContent *C.char, cJsonData *C.char, cEscapeHtml C._Bool, cUseMissingKeyZero C._Bool, cFailOnEmptyOutput C._Bool, cLineNumbers C._Bool, cRootKey *C.char, cMinifyType *C.char) C.RenderResult {
 	templateContent := C.GoString(cTemplateContent)
 	jsonData := C.GoString(cJsonData)
 	escapeHtml := bool(cEscapeHtml)
 	useMissingKeyZero := bool(cUseMissingKeyZero) // 将 C._Bool 转换为 Go bool
+	failOnEmptyOutput := bool(cFailOnEmptyOutput)
+	lineNumbers := bool(cLineNumbers)
+	rootKey := C.GoString(cRootKey)
+	minifyType := C.GoString(cMinifyType)
+
+	opts := positionalOptions(escapeHtml, useMissingKeyZero, failOnEmptyOutput, lineNumbers, rootKey, minifyType)
+	result := renderGoTemplate(templateContent, jsonData, opts)
+	return toCResult(result)
+}
 
-	result := renderGoTemplate(templateContent, jsonData, escapeHtml, useMissingKeyZero)
+// RenderWithOptions 是暴露给 C 的函数，所有渲染选项通过 JSON 对象传入，
+// 字段见 renderOptions。未知的键会被忽略，以保持向前兼容。
+//
+//export RenderWithOptions
+func RenderWithOptions(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderGoTemplate(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// RenderFromDataFile 是暴露给 C 的函数，由 Go 直接读取并解码数据文件，避免大数据经过 FFI 传递。
+// cFormat 为 json、yaml 或 toml；文件必须位于选项 dataBaseDir 指定的目录之内，
+// 其余选项与 RenderWithOptions 相同。
+//
+//export RenderFromDataFile
+func RenderFromDataFile(cTemplateContent *C.char, cDataFilePath *C.char, cFormat *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderDataFile(C.GoString(cTemplateContent), C.GoString(cDataFilePath), C.GoString(cFormat), opts)
+	return toCResult(result)
+}
+
+// renderWithFallback 先渲染主模板，失败时改用备用模板渲染。
+// 备用模板成功时，Output 为备用模板的输出，Error 仍保留主模板的错误，
+// 调用方可据此判断发生了降级。
+// JSON 数据解码失败时不会尝试备用模板，因为备用模板使用的是同一份数据。
+func renderWithFallback(mainSource string, fallbackSource string, jsonData string, opts renderOptions) RenderResult {
+	data, err := decodeTemplateData(jsonData, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal JSON data: %v", err),
+		}
+	}
+
+	result := executeGoTemplate(mainSource, data, opts)
+	if result.Error == "" {
+		return result
+	}
+
+	fallback := executeGoTemplate(fallbackSource, data, opts)
+	if fallback.Error != "" {
+		return RenderResult{
+			Error: fmt.Sprintf("Fallback template failed: %s (primary error: %s)", fallback.Error, result.Error),
+		}
+	}
+
+	return RenderResult{
+		Output: fallback.Output,
+		Error:  result.Error,
+	}
+}
 
+// RenderWithFallback 是暴露给 C 的函数，主模板失败时使用备用模板渲染。
+// 参数含义与 RenderTemplate 相同。
+//
+//export RenderWithFallback
+func RenderWithFallback(cMainSource *C.char, cFallbackSource *C.char, cJsonData *C.char, cEscapeHtml C._Bool, cUseMissingKeyZero C._Bool, cFailOnEmptyOutput C._Bool, cLineNumbers C._Bool, cRootKey *C.char, cMinifyType *C.char) C.RenderResult {
+	opts := positionalOptions(
+		bool(cEscapeHtml),
+		bool(cUseMissingKeyZero),
+		bool(cFailOnEmptyOutput),
+		bool(cLineNumbers),
+		C.GoString(cRootKey),
+		C.GoString(cMinifyType),
+	)
+	result := renderWithFallback(C.GoString(cMainSource), C.GoString(cFallbackSource), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// RenderAll 是暴露给 C 的函数，使用同一份数据渲染模板集合中每个非空的模板，
+// output 为 JSON 对象，键为模板名称，值为 {"output": ...} 或 {"error": ...}。
+// 开启 manifest 选项时 output 为 {"outputs": 上述对象, "manifest": [{"name", "length", "sha256"}, ...]}。
+// 选项与 RenderWithOptions 相同。
+//
+//export RenderAll
+func RenderAll(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderAll(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// RenderBatch 是暴露给 C 的函数，对 cDataJsonArray 数组中的每个数据对象渲染一次模板。
+// 数组按元素流式解码，解码出一个元素就渲染一个，不会先解码整个数组。
+// writeCallback 非 NULL 时，每个元素的结果都会立即传给回调（output 和 error 在回调返回后即被释放，
+// 成功时 error 为空字符串），回调返回 false 时停止，output 为 {"count", "failed", "stopped"}；
+// writeCallback 为 NULL 时 output 为 [{"index", "output", "error"}, ...] 形式的 JSON 数组。
+// 开启 manifest 选项时，前者增加 "manifest" 字段，后者改为 {"results": 上述数组, "manifest": [...]}，
+// 清单条目的 name 为元素下标。选项与 RenderWithOptions 相同。
+//
+//export RenderBatch
+func RenderBatch(cTemplateContent *C.char, cDataJsonArray *C.char, cOptionsJson *C.char, writeCallback C.batch_write_callback_t, userData unsafe.Pointer) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	// 直接读取 C 字符串的内存，避免为大数组复制一份 Go 字符串
+	var data []byte
+	if cDataJsonArray != nil {
+		data = unsafe.Slice((*byte)(unsafe.Pointer(cDataJsonArray)), int(C.strlen(cDataJsonArray)))
+	}
+	var write func(int, RenderResult) bool
+	if writeCallback != nil {
+		write = func(index int, result RenderResult) bool {
+			cOutput := C.CString(result.Output)
+			cError := C.CString(result.Error)
+			defer C.free(unsafe.Pointer(cOutput))
+			defer C.free(unsafe.Pointer(cError))
+			return bool(C.callBatchWriteCallback(writeCallback, userData, C.int(index), cOutput, cError))
+		}
+	}
+
+	result := renderBatch(C.GoString(cTemplateContent), bytes.NewReader(data), opts, write)
+	return toCResult(result)
+}
+
+// RenderMatrix 是暴露给 C 的函数，对 cMatrixJson（变量名到取值数组的对象）的每个组合渲染一次模板，
+// output 为 [{"params": ..., "output": ...}, ...] 形式的 JSON 数组，失败的组合带有 "error"。
+// 开启 manifest 选项时 output 改为 {"results": 上述数组, "manifest": [...]}，清单条目的 name 为组合的下标。
+// 组合数受选项 maxCombinations 限制，其余选项与 RenderWithOptions 相同。
+//
+//export RenderMatrix
+func RenderMatrix(cTemplateContent *C.char, cMatrixJson *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderMatrix(C.GoString(cTemplateContent), C.GoString(cMatrixJson), opts)
+	return toCResult(result)
+}
+
+// RenderWithDataChain 是暴露给 C 的函数，cDataJsonArray 为按优先级排列的数据候选数组，
+// 元素为 JSON 文档字符串或数据对象，null 表示该来源不存在。使用第一个非空且有效的候选渲染，
+// output 为 {"index": ..., "output": ...} 形式的 JSON；没有可用的候选时 error 列出每个候选被跳过的原因。
+// 选项与 RenderWithOptions 相同。
+//
+//export RenderWithDataChain
+func RenderWithDataChain(cTemplateContent *C.char, cDataJsonArray *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderWithDataChain(C.GoString(cTemplateContent), C.GoString(cDataJsonArray), opts)
+	return toCResult(result)
+}
+
+// RenderWithSourceMap 是暴露给 C 的函数，渲染模板并返回输出与模板源码之间的映射，
+// output 为 {"output": ..., "mappings": [{"start", "end", "template", "line", "column"}, ...]} 形式的 JSON，
+// 每个映射表示输出中 [start, end) 字节区间来自的模板名称及其行列（从 1 开始）。
+// 选项与 RenderWithOptions 相同，但不支持 minify、lineNumbers、lineEnding、postFilters 和 previewBytes。
+//
+//export RenderWithSourceMap
+func RenderWithSourceMap(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderWithSourceMap(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// GenerateSampleData 是暴露给 C 的函数，根据模板引用的数据路径生成一份示例数据，
+// output 为 JSON 对象，可以直接作为 RenderTemplate 的 jsonData 预览模板。
+// 在 range 中遍历的字段生成单元素数组，其余字段生成字符串或数字占位值。
+//
+//export GenerateSampleData
+func GenerateSampleData(cTemplateContent *C.char) C.RenderResult {
+	result := generateSampleData(C.GoString(cTemplateContent))
+	return toCResult(result)
+}
+
+// BeginRenderSession 是暴露给 C 的函数，开始一次交互式渲染并立即渲染一次。
+// output 为 {"session": id, "done": ..., "output": ..., "missingPath": [...]} 形式的 JSON：
+// done 为 true 时 output 为渲染结果，会话随之结束；否则 missingPath 为第一个缺失的键的路径
+// （字符串为键，数字为列表下标），通过 ProvideKey 补充后重新渲染。
+// 缺失的键总是视为错误，其余选项与 RenderWithOptions 相同；出现其他错误时返回 error，会话同样结束。
+//
+//export BeginRenderSession
+func BeginRenderSession(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := beginRenderSession(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// ProvideKey 是暴露给 C 的函数，将 cValueJson 写入会话数据中 cPathJson（JSON 数组）指定的位置并重新渲染，
+// 输出与 BeginRenderSession 相同
+//
+//export ProvideKey
+func ProvideKey(session C.longlong, cPathJson *C.char, cValueJson *C.char) C.RenderResult {
+	result := provideKey(int64(session), C.GoString(cPathJson), C.GoString(cValueJson))
+	return toCResult(result)
+}
+
+// EndRenderSession 是暴露给 C 的函数，放弃一个未完成的会话并释放其状态
+//
+//export EndRenderSession
+func EndRenderSession(session C.longlong) {
+	endRenderSession(int64(session))
+}
+
+// RenderToSharedMem 是暴露给 C 的函数，将渲染结果写入共享内存（/dev/shm，不可用时为临时目录）中的文件，
+// output 为 {"name": 文件路径, "size": 字节数} 形式的 JSON，调用方以只读方式映射该文件，
+// 从而避免大段输出经过 FFI 复制。使用完毕后必须调用 ReleaseSharedMem 删除文件。
+// 选项与 RenderWithOptions 相同。
+//
+//export RenderToSharedMem
+func RenderToSharedMem(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderToSharedMem(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCResult(result)
+}
+
+// ReleaseSharedMem 是暴露给 C 的函数，删除 RenderToSharedMem 创建的文件，
+// cName 不是由 RenderToSharedMem 创建或已经释放时返回 error
+//
+//export ReleaseSharedMem
+func ReleaseSharedMem(cName *C.char) C.RenderResult {
+	result := releaseSharedMem(C.GoString(cName))
+	return toCResult(result)
+}
+
+// RegisterProtoDescriptors 是暴露给 C 的函数，注册 RenderTemplateProto 使用的消息类型。
+// cDescriptorSet 为长度 cLen 的序列化 FileDescriptorSet，可以由 protoc --descriptor_set_out --include_imports 生成，
+// 注册在进程内一直有效，成功时 output 和 error 均为空。
+//
+//export RegisterProtoDescriptors
+func RegisterProtoDescriptors(cDescriptorSet *C.char, cLen C.int) C.RenderResult {
+	result := registerProtoDescriptors(C.GoBytes(unsafe.Pointer(cDescriptorSet), cLen))
+	return toCResult(result)
+}
+
+// RenderTemplateProto 是暴露给 C 的函数，使用长度为 cLen 的 protobuf 数据渲染模板，
+// cMessageType 为已通过 RegisterProtoDescriptors 注册的消息的完整名称（如 "pkg.Message"）。
+// 消息会被转换为以 .proto 字段名为键的 map，其余选项与 RenderWithOptions 相同。
+//
+//export RenderTemplateProto
+func RenderTemplateProto(cTemplateContent *C.char, cProtoBytes *C.char, cLen C.int, cMessageType *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	data := C.GoBytes(unsafe.Pointer(cProtoBytes), cLen)
+	result := renderProto(C.GoString(cTemplateContent), data, C.GoString(cMessageType), opts)
+	return toCResult(result)
+}
+
+// RenderAndAssert 是暴露给 C 的函数，渲染模板并与 cExpected 比较，用于快照测试。
+// 输出相同时 output 和 error 均为空；不同时 error 为逐行差异，渲染失败时 error 为渲染错误。
+// cNormalizeWhitespace 为 true 时忽略换行符形式、每行首尾的空白、行内空白的数量以及开头和结尾的空行。
+// 选项与 RenderWithOptions 相同。
+//
+//export RenderAndAssert
+func RenderAndAssert(cTemplateContent *C.char, cJsonData *C.char, cExpected *C.char, cOptionsJson *C.char, cNormalizeWhitespace C._Bool) C.RenderResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := renderAndAssert(C.GoString(cTemplateContent), C.GoString(cJsonData), C.GoString(cExpected), bool(cNormalizeWhitespace), opts)
+	return toCResult(result)
+}
+
+// VerifyChecksum 是暴露给 C 的函数，校验通过 embedChecksum 选项渲染的内容。
+// cCommentPrefix 为渲染时使用的注释前缀（#、// 或 <!--），输出为 {"valid", "expected", "actual"}，
+// 最后一行不是校验和注释时返回错误。
+//
+//export VerifyChecksum
+func VerifyChecksum(cContent *C.char, cCommentPrefix *C.char) C.RenderResult {
+	return toCResult(verifyChecksum(C.GoString(cContent), C.GoString(cCommentPrefix)))
+}
+
+// renderBytes 渲染模板，并按 opts.OutputEncoding 转换输出的字符集
+func renderBytes(templateContent string, jsonData string, opts renderOptions) ([]byte, string) {
+	result := renderGoTemplate(templateContent, jsonData, opts)
+	if result.Error != "" || opts.OutputEncoding == "" {
+		return []byte(result.Output), result.Error
+	}
+
+	output, err := encodeOutput(result.Output, opts.OutputEncoding, opts.ReplaceUnencodable)
+	if err != nil {
+		logf(logError, "failed to encode output: %v", err)
+		return nil, fmt.Sprintf("Failed to encode output: %v", err)
+	}
+	return output, ""
+}
+
+// RenderBytes 是暴露给 C 的函数，选项与 RenderWithOptions 相同，另外支持 outputEncoding。
+// 输出以 data 和 length 表示，可以包含 NUL 字节；data 和 error 都通过 FreeResultString 释放。
+//
+//export RenderBytes
+func RenderBytes(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.BytesResult {
+	opts, err := parseRenderOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCBytesResult(nil, fmt.Sprintf("Failed to parse render options: %v", err))
+	}
+
+	output, errMsg := renderBytes(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	return toCBytesResult(output, errMsg)
+}
+
+// RenderLines 是暴露给 C 的函数，选项与 RenderWithOptions 相同，输出按 "\n" 拆分为 count 个 C 字符串。
+// 行中不包含 "\n"，"\r" 会保留（需要时可以配合 lineEnding 选项）；
+// 拆分与 strings.Split 一致，count 总是换行符数量加一，因此以换行符结尾的输出最后一个元素为空字符串，
+// 空输出得到一个空字符串。lines 通过 FreeStringArray 释放，error 通过 FreeResultString 释放。
+//
+//export RenderLines
+func RenderLines(cTemplateContent *C.char, cJsonData *C.char, cOptionsJson *C.char) C.LinesResult {
+	opts, err := parseStringOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCLinesResult(nil, fmt.Sprintf("Failed to parse render options: %v", err))
+	}
+
+	result := renderGoTemplate(C.GoString(cTemplateContent), C.GoString(cJsonData), opts)
+	if result.Error != "" {
+		return toCLinesResult(nil, result.Error)
+	}
+	return toCLinesResult(strings.Split(result.Output, "\n"), "")
+}
+
+// CheckAllowedFuncs 是暴露给 C 的函数，在不执行模板的情况下检查其调用的函数是否都在允许列表中。
+// cAllowedJson 为函数名组成的 JSON 数组，检查通过时 output 和 error 均为空，
+// 否则 error 列出所有不允许的函数。模板使用默认的 {{ 和 }} 分隔符。
+//
+//export CheckAllowedFuncs
+func CheckAllowedFuncs(cTemplateContent *C.char, cAllowedJson *C.char) C.RenderResult {
+	result := checkAllowedFuncs(C.GoString(cTemplateContent), C.GoString(cAllowedJson), renderOptions{})
+	return toCResult(result)
+}
+
+// CheckAllowedFuncsWithOptions 与 CheckAllowedFuncs 相同，但按 cOptionsJson 中的 leftDelim 和 rightDelim 解析模板，
+// 这样使用自定义分隔符渲染的模板也能检查。cOptionsJson 与 RenderWithOptions 的选项格式相同，其余选项被忽略。
+//
+//export CheckAllowedFuncsWithOptions
+func CheckAllowedFuncsWithOptions(cTemplateContent *C.char, cAllowedJson *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseRenderOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := checkAllowedFuncs(C.GoString(cTemplateContent), C.GoString(cAllowedJson), opts)
+	return toCResult(result)
+}
+
+// CheckTemplateSet 是暴露给 C 的函数，在不执行的情况下检查一组模板文件并汇总所有问题，适用于 CI 和提交前检查。
+// cNamesJson 和 cSourcesJson 为等长的 JSON 字符串数组，分别为文件名和源码；cAllowedFuncsJson 为允许的函数名数组，
+// 为空字符串或 null 时只检查函数是否已注册。output 为 {"ok": ..., "files": [{"name", "parseError",
+// "unknownFuncs", "disabledFuncs", "disallowedFuncs", "unresolvedTemplates", "recursiveTemplates"}, ...]} 形式的 JSON，
+// disabledFuncs 为需要通过选项开启的函数（如 renderString）；recursiveTemplates 为经引用链可以回到自身的模板，
+// 仅作提示，不影响 ok。只有输入本身无效时才返回 error。
+//
+//export CheckTemplateSet
+func CheckTemplateSet(cNamesJson *C.char, cSourcesJson *C.char, cAllowedFuncsJson *C.char) C.RenderResult {
+	result := checkTemplateSet(C.GoString(cNamesJson), C.GoString(cSourcesJson), C.GoString(cAllowedFuncsJson))
+	return toCResult(result)
+}
+
+// IsTemplateDeterministic 是暴露给 C 的函数，在不执行模板的情况下检查其输出是否只取决于输入数据，
+// output 为 {"deterministic": ..., "funcs": [...]} 形式的 JSON，funcs 为调用到的非确定性函数（如 timeAgo）。
+// 调用 timeAgo 的模板在设置 referenceTime 选项后同样是确定的，这里不考虑渲染选项。模板使用默认的 {{ 和 }} 分隔符。
+//
+//export IsTemplateDeterministic
+func IsTemplateDeterministic(cTemplateContent *C.char) C.RenderResult {
+	result := checkDeterministic(C.GoString(cTemplateContent), renderOptions{})
+	return toCResult(result)
+}
+
+// IsTemplateDeterministicWithOptions 与 IsTemplateDeterministic 相同，但按 cOptionsJson 中的 leftDelim 和 rightDelim 解析模板。
+// cOptionsJson 与 RenderWithOptions 的选项格式相同，其余选项被忽略。
+//
+//export IsTemplateDeterministicWithOptions
+func IsTemplateDeterministicWithOptions(cTemplateContent *C.char, cOptionsJson *C.char) C.RenderResult {
+	opts, err := parseRenderOptions(C.GoString(cOptionsJson))
+	if err != nil {
+		return toCResult(RenderResult{
+			Error: fmt.Sprintf("Failed to parse render options: %v", err),
+		})
+	}
+
+	result := checkDeterministic(C.GoString(cTemplateContent), opts)
+	return toCResult(result)
+}
+
+// toCBytesResult 将输出字节和错误信息转换为 C 结构体
+func toCBytesResult(output []byte, errMsg string) C.BytesResult {
+	return C.BytesResult{
+		data:   (*C.char)(C.CBytes(output)),
+		length: C.size_t(len(output)),
+		error:  C.CString(errMsg),
+	}
+}
+
+// toCLinesResult 将各行和错误信息转换为 C 结构体，出错时 lines 为 NULL、count 为 0
+func toCLinesResult(lines []string, errMsg string) C.LinesResult {
+	result := C.LinesResult{error: C.CString(errMsg)}
+	if len(lines) == 0 {
+		return result
+	}
+	arr := (**C.char)(C.malloc(C.size_t(len(lines)) * C.size_t(unsafe.Sizeof((*C.char)(nil)))))
+	cLines := unsafe.Slice(arr, len(lines))
+	for i, line := range lines {
+		cLines[i] = C.CString(line)
+	}
+	result.lines = arr
+	result.count = C.int(len(lines))
+	return result
+}
+
+// toCResult 将 Go 的渲染结果转换为 C 结构体，字符串由调用方通过 FreeResultString 释放。
+func toCResult(result RenderResult) C.RenderResult {
 	cOutput := C.CString(result.Output)
 	cError := C.CString(result.Error)
 
@@ -115,6 +707,19 @@ func FreeResultString(cStr *C.char) {
 	C.free(unsafe.Pointer(cStr))
 }
 
+// FreeStringArray 释放 RenderLines 返回的字符串数组，包括其中的每个字符串，arr 为 NULL 时不做任何操作
+//
+//export FreeStringArray
+func FreeStringArray(arr **C.char, count C.int) {
+	if arr == nil {
+		return
+	}
+	for _, s := range unsafe.Slice(arr, int(count)) {
+		C.free(unsafe.Pointer(s))
+	}
+	C.free(unsafe.Pointer(arr))
+}
+
 func main() {
 	// main 函数必须存在，但在这里是空的，因为我们是编译为 C 共享库。
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// 主模板失败时使用备用模板的输出，并在 Error 中保留主模板的错误；两者都失败时只返回错误
+func TestRenderWithFallback(t *testing.T) {
+	tests := []struct {
+		name       string
+		main       string
+		fallback   string
+		jsonData   string
+		wantOutput string
+		wantErr    []string
+	}{
+		{"primary succeeds", `hi {{ .name }}`, `fallback`, `{"name": "x"}`, "hi x", nil},
+		{"primary fails", `{{ fail "boom" }}`, `fallback {{ .name }}`, `{"name": "x"}`, "fallback x", []string{"boom"}},
+		{"primary does not parse", `{{ if }}`, `fallback`, `{}`, "fallback", []string{"Failed to parse Text template"}},
+		{"both fail", `{{ fail "first" }}`, `{{ fail "second" }}`, `{}`, "", []string{"Fallback template failed: ", "second", "(primary error: ", "first"}},
+		{"invalid data", `main`, `fallback`, `{`, "", []string{"Failed to unmarshal JSON data"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderWithFallback(tt.main, tt.fallback, tt.jsonData, renderOptions{})
+			if result.Output != tt.wantOutput {
+				t.Errorf("got output %q, want %q", result.Output, tt.wantOutput)
+			}
+			if len(tt.wantErr) == 0 && result.Error != "" {
+				t.Errorf("unexpected error: %s", result.Error)
+			}
+			if len(tt.wantErr) > 0 && result.Error == "" {
+				t.Errorf("got no error, want one containing %q", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+		})
+	}
+}
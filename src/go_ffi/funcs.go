@@ -0,0 +1,120 @@
+package main
+
+// builtinFuncs 返回注册到每个模板上的辅助函数集合。
+// html/template 与 text/template 的 FuncMap 底层类型相同，因此共用同一份定义。
+// 需要访问模板集合本身的函数见 templateSet.funcs，依赖当前时间的函数见 timeFuncs，
+// 带有渲染内状态的计数器见 counterFuncs，读取渲染选项中常量的 const 见 constFuncs。
+func builtinFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		// 字符串
+		"splitList": splitList,
+		"join":      join,
+		"splitN":    splitN,
+		"trimAll":   trimAll,
+		"camelcase": camelcase,
+		"snakecase": snakecase,
+		"kebabcase": kebabcase,
+		"slugify":   slugify,
+		"truncate":  truncate,
+		"abbrev":    abbrev,
+		"indent":    indent,
+		"nindent":   nindent,
+
+		// 脱敏
+		"mask":   mask,
+		"redact": redact,
+
+		// 逻辑
+		"ifNil":       ifNil,
+		"coalesceNil": coalesceNil,
+		"required":    required,
+		"getOr":       getOr,
+		"fail":        fail,
+		"failIf":      failIf,
+		"allOf":       allOf,
+		"anyOf":       anyOf,
+		"noneOf":      noneOf,
+
+		// 列表
+		"at":    at,
+		"first": first,
+		"last":  last,
+		"rest":  rest,
+
+		// 集合
+		"uniq":         uniq,
+		"union":        union,
+		"intersection": intersection,
+		"difference":   difference,
+
+		// 字典
+		"merge":          merge,
+		"mergeOverwrite": mergeOverwrite,
+		"deepCopy":       deepCopy,
+
+		// 数学
+		"add":     add,
+		"sub":     sub,
+		"mul":     mul,
+		"div":     div,
+		"mod":     mod,
+		"max":     scalarMax,
+		"min":     scalarMin,
+		"round":   round,
+		"percent": percent,
+		"ratio":   ratio,
+
+		// 统计
+		"sum":    sum,
+		"avg":    avg,
+		"minOf":  minOf,
+		"maxOf":  maxOf,
+		"median": median,
+
+		// 数字格式
+		"ordinal":     ordinal,
+		"spellNumber": spellNumber,
+
+		// 时长
+		"parseDuration":  parseDuration,
+		"formatDuration": formatDuration,
+		"durationLt":     durationLt,
+		"durationGt":     durationGt,
+		"durationEq":     durationEq,
+
+		// 颜色
+		"colorHash":    colorHash,
+		"colorHashHSL": colorHashHSL,
+
+		// 表格
+		"table": table,
+
+		// 版本
+		"semverCompare": semverCompare,
+		"semverLt":      semverLt,
+		"semverGt":      semverGt,
+		"semverEq":      semverEq,
+
+		// 编码
+		"toQuery":  toQuery,
+		"fromJSON": fromJSON,
+		"fromYAML": fromYAML,
+		"hexEnc":   hexEnc,
+		"hexDec":   hexDec,
+		"toBytes":  toBytes,
+
+		// Shell
+		"shellQuote": shellQuote,
+		"quoteList":  quoteList,
+
+		// 路径
+		"pathJoin":  pathJoin,
+		"pathClean": pathClean,
+
+		// 模板
+		"templateLine": templateLine,
+
+		// 代码生成
+		"goLiteral": goLiteral,
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// goLiteral 将值格式化为合法的 Go 源码字面量，用于代码生成。
+// 列表和 map 会根据元素推断类型（如 []string、map[string]int），
+// 元素类型不一致时退化为 interface{}；map 的键按字典序排列以保证输出稳定。
+func goLiteral(v interface{}) (string, error) {
+	var b strings.Builder
+	if err := writeGoLiteral(&b, v); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeGoLiteral(b *strings.Builder, v interface{}) error {
+	switch val := v.(type) {
+	case nil:
+		b.WriteString("nil")
+	case string:
+		b.WriteString(strconv.Quote(val))
+	case bool:
+		b.WriteString(strconv.FormatBool(val))
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		fmt.Fprintf(b, "%d", val)
+	case float32:
+		return writeGoLiteral(b, float64(val))
+	case float64:
+		if math.IsInf(val, 0) || math.IsNaN(val) {
+			return fmt.Errorf("cannot represent %v as a literal", val)
+		}
+		b.WriteString(formatGoFloat(val))
+	case json.Number:
+		b.WriteString(val.String())
+	case []string:
+		items := make([]interface{}, len(val))
+		for i, s := range val {
+			items[i] = s
+		}
+		return writeGoSlice(b, items)
+	case []interface{}:
+		return writeGoSlice(b, val)
+	case map[string]interface{}:
+		return writeGoMap(b, val)
+	default:
+		return fmt.Errorf("unsupported type %T", v)
+	}
+	return nil
+}
+
+func writeGoSlice(b *strings.Builder, items []interface{}) error {
+	elemType, err := goLiteralElemType(items)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("[]" + elemType + "{")
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeGoLiteral(b, item); err != nil {
+			return err
+		}
+	}
+	b.WriteString("}")
+	return nil
+}
+
+func writeGoMap(b *strings.Builder, m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	elemType, err := goLiteralElemType(values)
+	if err != nil {
+		return err
+	}
+
+	b.WriteString("map[string]" + elemType + "{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.Quote(k))
+		b.WriteString(": ")
+		if err := writeGoLiteral(b, m[k]); err != nil {
+			return err
+		}
+	}
+	b.WriteString("}")
+	return nil
+}
+
+// goLiteralElemType 推断一组值共同的 Go 类型，类型不一致或为空时返回 interface{}。
+// 整数与小数混合时提升为 float64。
+func goLiteralElemType(values []interface{}) (string, error) {
+	common := ""
+	for _, v := range values {
+		t, err := goLiteralType(v)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case common == "":
+			common = t
+		case common == t:
+		case (common == "int" && t == "float64") || (common == "float64" && t == "int"):
+			common = "float64"
+		default:
+			return "interface{}", nil
+		}
+	}
+	if common == "" {
+		return "interface{}", nil
+	}
+	return common, nil
+}
+
+func goLiteralType(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "interface{}", nil
+	case string:
+		return "string", nil
+	case bool:
+		return "bool", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return "int", nil
+	case float32:
+		return goLiteralType(float64(val))
+	case float64:
+		if val == math.Trunc(val) && math.Abs(val) < 1e15 {
+			return "int", nil
+		}
+		return "float64", nil
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return "int", nil
+		}
+		return "float64", nil
+	case []string:
+		return "[]string", nil
+	case []interface{}:
+		elemType, err := goLiteralElemType(val)
+		return "[]" + elemType, err
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(val))
+		for _, e := range val {
+			values = append(values, e)
+		}
+		elemType, err := goLiteralElemType(values)
+		return "map[string]" + elemType, err
+	default:
+		return "", fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// formatGoFloat 输出最短的浮点表示，整数值不带小数部分。
+func formatGoFloat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"math"
+	"testing"
+)
+
+// goLiteral 根据元素推断列表和 map 的类型，输出必须是合法的 Go 表达式
+func TestGoLiteral(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"null", `null`, `nil`},
+		{"string", `"a\"b\n"`, `"a\"b\n"`},
+		{"unicode", `"é"`, `"é"`},
+		{"bool", `true`, `true`},
+		{"integer float", `3`, `3`},
+		{"fraction", `2.5`, `2.5`},
+		{"large", `1e20`, `1e+20`},
+		{"negative", `-7`, `-7`},
+		{"string list", `["a", "b"]`, `[]string{"a", "b"}`},
+		{"int list", `[1, 2]`, `[]int{1, 2}`},
+		{"int and float", `[1, 2.5]`, `[]float64{1, 2.5}`},
+		{"mixed list", `[1, "a"]`, `[]interface{}{1, "a"}`},
+		{"null in list", `[null, 1]`, `[]interface{}{nil, 1}`},
+		{"empty list", `[]`, `[]interface{}{}`},
+		{"nested lists", `[[1], [2, 3]]`, `[][]int{[]int{1}, []int{2, 3}}`},
+		{"nested mixed", `[[1], ["a"]]`, `[]interface{}{[]int{1}, []string{"a"}}`},
+		{"map sorted", `{"b": 2, "a": 1}`, `map[string]int{"a": 1, "b": 2}`},
+		{"map mixed", `{"a": 1, "b": true}`, `map[string]interface{}{"a": 1, "b": true}`},
+		{"empty map", `{}`, `map[string]interface{}{}`},
+		{"map of lists", `{"x": ["a"]}`, `map[string][]string{"x": []string{"a"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.json), &v); err != nil {
+				t.Fatal(err)
+			}
+			got, err := goLiteral(v)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Errorf("%s is not a valid Go expression: %v", got, err)
+			}
+		})
+	}
+}
+
+func TestGoLiteralErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{"infinity", math.Inf(1)},
+		{"NaN", math.NaN()},
+		{"NaN in list", []interface{}{1.0, math.NaN()}},
+		{"unsupported type", struct{}{}},
+		{"unsupported in map", map[string]interface{}{"a": struct{}{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := goLiteral(tt.v); err == nil {
+				t.Errorf("got %s, want an error", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// colorSeed 返回 s 的 FNV-1a 哈希。该算法与平台和进程无关，同一输入在任何机器上都得到相同的颜色。
+func colorSeed(s interface{}) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(toString(s)))
+	return h.Sum32()
+}
+
+// colorHash 将字符串映射为固定的十六进制颜色，例如 "#a1b2c3"
+func colorHash(s interface{}) string {
+	return fmt.Sprintf("#%06x", colorSeed(s)&0xffffff)
+}
+
+// colorHashHSL 与 colorHash 相同，但只由哈希决定色相，饱和度和亮度（0 到 100 的百分比）由参数指定，
+// 便于让一组标签的颜色同样醒目或同样柔和。用法：colorHashHSL 65 50 .label
+func colorHashHSL(saturation interface{}, lightness interface{}, s interface{}) (string, error) {
+	sat, err := toNumber(saturation)
+	if err != nil {
+		return "", err
+	}
+	light, err := toNumber(lightness)
+	if err != nil {
+		return "", err
+	}
+	if sat.float() < 0 || sat.float() > 100 || light.float() < 0 || light.float() > 100 {
+		return "", errors.New("saturation and lightness must be between 0 and 100")
+	}
+
+	hue := float64(colorSeed(s) % 360)
+	r, g, b := hslToRGB(hue, sat.float()/100, light.float()/100)
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b), nil
+}
+
+// hslToRGB 将色相（0 到 360 度）、饱和度和亮度（0 到 1）转换为 RGB 分量
+func hslToRGB(h float64, s float64, l float64) (uint8, uint8, uint8) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	component := func(v float64) uint8 {
+		return uint8(math.Round((v + m) * 255))
+	}
+	return component(r), component(g), component(b)
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// colorHash 取 FNV-1a 哈希的低 24 位，结果与平台无关；非字符串值先转换为字符串
+func TestColorHash(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{"", "#1c9dc5"},
+		{"a", "#0c292c"},
+		{"backend", "#edd44f"},
+		{1000000.0, "#c1039c"},
+		{"1000000", "#c1039c"},
+		{nil, "#1c9dc5"},
+	}
+	for _, tt := range tests {
+		if got := colorHash(tt.in); got != tt.want {
+			t.Errorf("colorHash(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// hslToRGB 在各个色相区间的边界上得到纯色，饱和度为 0 时得到灰色
+func TestHSLToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, l float64
+		rgb     [3]uint8
+	}{
+		{0, 1, 0.5, [3]uint8{255, 0, 0}},
+		{60, 1, 0.5, [3]uint8{255, 255, 0}},
+		{120, 1, 0.5, [3]uint8{0, 255, 0}},
+		{180, 1, 0.5, [3]uint8{0, 255, 255}},
+		{240, 1, 0.5, [3]uint8{0, 0, 255}},
+		{300, 1, 0.5, [3]uint8{255, 0, 255}},
+		{30, 1, 0.5, [3]uint8{255, 128, 0}},
+		{0, 0, 0.5, [3]uint8{128, 128, 128}},
+		{200, 1, 0, [3]uint8{0, 0, 0}},
+		{200, 1, 1, [3]uint8{255, 255, 255}},
+		{0, 1, 0.25, [3]uint8{128, 0, 0}},
+	}
+	for _, tt := range tests {
+		r, g, b := hslToRGB(tt.h, tt.s, tt.l)
+		if got := [3]uint8{r, g, b}; got != tt.rgb {
+			t.Errorf("hslToRGB(%v, %v, %v) = %v, want %v", tt.h, tt.s, tt.l, got, tt.rgb)
+		}
+	}
+}
+
+// colorHashHSL 的色相为哈希对 360 取模，饱和度和亮度必须在 0 到 100 之间
+func TestColorHashHSL(t *testing.T) {
+	runTemplateCases(t, `{"label": "backend", "n": 1000000}`, []templateCase{
+		{"hue 31", `{{ colorHashHSL 100 50 .label }}`, "#ff8400", false},
+		{"hue 300", `{{ colorHashHSL 100 50 .n }}`, "#ff00ff", false},
+		{"hue 340", `{{ colorHashHSL 100 50 "a" }}`, "#ff0055", false},
+		{"pipeline", `{{ .label | colorHashHSL 100 50 }}`, "#ff8400", false},
+		{"float percentages", `{{ colorHashHSL 100.0 50.0 .label }}`, "#ff8400", false},
+		{"string percentage", `{{ colorHashHSL "100" 50 .label }}`, "", true},
+		{"no saturation", `{{ colorHashHSL 0 50 .label }}`, "#808080", false},
+		{"black", `{{ colorHashHSL 65 0 .label }}`, "#000000", false},
+		{"white", `{{ colorHashHSL 65 100 .label }}`, "#ffffff", false},
+		{"colorHash", `{{ colorHash .label }}`, "#edd44f", false},
+		{"saturation too high", `{{ colorHashHSL 101 50 .label }}`, "", true},
+		{"negative lightness", `{{ colorHashHSL 50 -1 .label }}`, "", true},
+		{"non-numeric saturation", `{{ colorHashHSL "x" 50 .label }}`, "", true},
+		{"non-numeric lightness", `{{ colorHashHSL 50 "x" .label }}`, "", true},
+	})
+}
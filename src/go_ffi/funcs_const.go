@@ -0,0 +1,18 @@
+package main
+
+import "fmt"
+
+// constFuncs 返回读取渲染选项中常量的 const 函数。
+// 常量与数据分开传入，不会出现在 range 遍历的根数据中，也不会与数据中的同名键冲突。
+func constFuncs(constants map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		// const 返回指定名称的常量，未定义的名称视为执行错误，以便及早发现拼写错误
+		"const": func(name string) (interface{}, error) {
+			v, ok := constants[name]
+			if !ok {
+				return nil, fmt.Errorf("undefined constant %q", name)
+			}
+			return v, nil
+		},
+	}
+}
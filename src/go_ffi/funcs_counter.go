@@ -0,0 +1,34 @@
+package main
+
+// counterFuncs 返回一组共享状态的计数器函数，以及清空全部计数器的 reset。
+// 每次解析模板都会重新调用，因此计数器不会泄漏到其他调用；同一次解析执行多次时
+// （RenderAll、RenderMatrix、RenderBatch），每次执行前需要调用 reset，使计数器只在一次渲染内有效。
+// 计数器没有加锁，只能由一个执行使用，templateSet.renderOnce 保证超时后不再复用同一份计数器。
+func counterFuncs() (map[string]interface{}, func()) {
+	counters := map[string]int64{}
+	reset := func() {
+		for name := range counters {
+			delete(counters, name)
+		}
+	}
+	return map[string]interface{}{
+		// newCounter 创建或重置计数器，之后第一次 next 返回 start（默认为 1），本身不输出内容
+		"newCounter": func(name string, start ...interface{}) (string, error) {
+			first := int64(1)
+			if len(start) > 0 {
+				n, err := toInt(start[0])
+				if err != nil {
+					return "", err
+				}
+				first = int64(n)
+			}
+			counters[name] = first - 1
+			return "", nil
+		},
+		// next 将计数器加一并返回新值，未创建的计数器从 1 开始
+		"next": func(name string) int64 {
+			counters[name]++
+			return counters[name]
+		},
+	}, reset
+}
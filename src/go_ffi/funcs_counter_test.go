@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 同一份解析结果多次执行时，计数器在每次执行中都应当从 1 开始
+func TestCountersResetBetweenExecutions(t *testing.T) {
+	t.Run("RenderAll", func(t *testing.T) {
+		result := renderAll(`{{ define "x" }}{{ next "row" }}{{ end }}{{ define "y" }}{{ next "row" }}{{ end }}`, `{}`, renderOptions{})
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		var outputs map[string]namedOutput
+		if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"x", "y"} {
+			if got := outputs[name].Output; got != "1" {
+				t.Errorf("template %q rendered %q, want %q", name, got, "1")
+			}
+		}
+	})
+
+	t.Run("RenderMatrix", func(t *testing.T) {
+		result := renderMatrix(`{{ next "row" }}`, `{"a": [1, 2, 3]}`, renderOptions{})
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		var outputs []matrixOutput
+		if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != 3 {
+			t.Fatalf("got %d combinations, want 3", len(outputs))
+		}
+		for i, output := range outputs {
+			if output.Output != "1" {
+				t.Errorf("combination %d rendered %q, want %q", i, output.Output, "1")
+			}
+		}
+	})
+
+	t.Run("RenderBatch", func(t *testing.T) {
+		var outputs []string
+		result := renderBatch(`{{ next "row" }}{{ next "row" }}`, strings.NewReader(`[{"a": 1}, {"a": 2}, {"a": 3}]`), renderOptions{}, func(index int, result RenderResult) bool {
+			outputs = append(outputs, result.Output)
+			return true
+		})
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		if got := strings.Join(outputs, ","); got != "12,12,12" {
+			t.Errorf("got outputs %q, want %q", got, "12,12,12")
+		}
+	})
+}
+
+// 计数器在一次渲染内跨越 range 和 define 持续递增，newCounter 可以指定起始值
+func TestCounters(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"sequence", `{{ next "a" }}{{ next "a" }}{{ next "b" }}`, "121"},
+		{"across range", `{{ range .items }}{{ next "row" }}{{ end }}`, "123"},
+		{"across define", `{{ define "id" }}{{ next "row" }}{{ end }}{{ template "id" }}{{ template "id" }}`, "12"},
+		{"newCounter", `{{ newCounter "a" 10 }}{{ next "a" }}{{ next "a" }}`, "1011"},
+		{"reset", `{{ next "a" }}{{ next "a" }}{{ newCounter "a" }}{{ next "a" }}`, "121"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(tt.template, `{"items": [1, 2, 3]}`, renderOptions{})
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// 超时的执行在后台继续调用 next，之后的执行不能与它共用计数器（go test -race 可以发现共用时的竞争）
+func TestCountersAfterTimeout(t *testing.T) {
+	template := `{{ if .slow }}` + slowCounterTemplate + `{{ else }}{{ next "c" }}{{ next "c" }}{{ end }}`
+	opts := renderOptions{TimeoutMs: 50}
+	// n 为 slowCounterData 中的 "n": [...] 成员
+	n := strings.TrimPrefix(strings.TrimSuffix(slowCounterData(), "}"), "{")
+	list := strings.TrimPrefix(n, `"n": `)
+
+	t.Run("RenderMatrix", func(t *testing.T) {
+		result := renderMatrix(template, `{"slow": [true, true, true, false], "n": [`+list+`]}`, opts)
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		var outputs []matrixOutput
+		if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != 4 {
+			t.Fatalf("got %d combinations, want 4", len(outputs))
+		}
+		for i, output := range outputs[:3] {
+			if !strings.Contains(output.Error, "timed out") {
+				t.Errorf("combination %d: got %+v, want a timeout", i, output.Error)
+			}
+		}
+		if got := outputs[3].Output; got != "12" || outputs[3].Error != "" {
+			t.Errorf("last combination: got %+v, want output %q", outputs[3], "12")
+		}
+	})
+
+	t.Run("RenderBatch", func(t *testing.T) {
+		slow := `{"slow": true, ` + n + `}`
+		var outputs []RenderResult
+		result := renderBatch(template, strings.NewReader(`[`+slow+`, `+slow+`, `+slow+`, {"slow": false}]`), opts, func(index int, result RenderResult) bool {
+			outputs = append(outputs, result)
+			return true
+		})
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		for i, output := range outputs[:3] {
+			if !strings.Contains(output.Error, "timed out") {
+				t.Errorf("element %d: got %+v, want a timeout", i, output)
+			}
+		}
+		if got := outputs[3]; got.Output != "12" || got.Error != "" {
+			t.Errorf("last element: got %+v, want output %q", got, "12")
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package main
+
+import "fmt"
+
+// merge 将 srcs 中的顶层键依次浅合并到 dst 中：dst 中已有的非 nil 值保留，缺失或为 nil 的键取第一个提供它的 src，
+// 嵌套的 map 不会递归合并。与 Sprig 不同的是 dst 和 srcs 都不会被修改，合并结果作为新的 map 返回，
+// 避免改动模板数据后影响模板中其他使用同一份数据的地方，因此需要使用返回值：$m := merge $a $b。
+func merge(dst interface{}, srcs ...interface{}) (map[string]interface{}, error) {
+	return mergeAll(dst, srcs, fillMissing)
+}
+
+// mergeOverwrite 将 srcs 依次深度合并到 dst 中，srcs 中的非 nil 值会覆盖 dst 中已有的值，后面的参数优先。
+// 与 merge 一样不会修改参数。
+func mergeOverwrite(dst interface{}, srcs ...interface{}) (map[string]interface{}, error) {
+	return mergeAll(dst, srcs, mergeMaps)
+}
+
+func mergeAll(dst interface{}, srcs []interface{}, combine func(dst map[string]interface{}, src map[string]interface{}) map[string]interface{}) (map[string]interface{}, error) {
+	out, err := toMap(dst)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range srcs {
+		m, err := toMap(src)
+		if err != nil {
+			return nil, err
+		}
+		out = combine(out, m)
+	}
+	return out, nil
+}
+
+// fillMissing 返回 dst 的浅拷贝，其中缺失或为 nil 的顶层键取自 src
+func fillMissing(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, sv := range src {
+		if out[k] == nil {
+			out[k] = sv
+		}
+	}
+	return out
+}
+
+// mergeMaps 返回 dst 与 src 深度合并后的新 map，src 中的非 nil 值优先。
+// 只复制需要修改的层级，未修改的嵌套值与输入共享，因此输入必须视为只读。
+func mergeMaps(dst map[string]interface{}, src map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(dst)+len(src))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, sv := range src {
+		dv := out[k]
+		dm, dstIsMap := dv.(map[string]interface{})
+		sm, srcIsMap := sv.(map[string]interface{})
+		switch {
+		case dstIsMap && srcIsMap:
+			out[k] = mergeMaps(dm, sm)
+		case dv == nil, sv != nil:
+			out[k] = sv
+		}
+	}
+	return out
+}
+
+// deepCopy 返回 v 的深拷贝，map 和列表会逐级复制，其他值原样返回
+func deepCopy(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return v
+}
+
+// toMap 将 JSON 对象转换为 map，nil 视为空 map，其他类型返回错误
+func toMap(v interface{}) (map[string]interface{}, error) {
+	switch m := v.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return m, nil
+	}
+	return nil, fmt.Errorf("expected a map, got %T", v)
+}
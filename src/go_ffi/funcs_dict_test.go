@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeMap 将 JSON 对象解码为模板数据中的 map
+func decodeMap(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// merge 只合并顶层键，mergeOverwrite 深度合并，两者都不修改参数
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(interface{}, ...interface{}) (map[string]interface{}, error)
+		dst  string
+		srcs []string
+		want string
+	}{
+		{"merge keeps dst", merge, `{"a": 1}`, []string{`{"a": 2, "b": 2}`}, `{"a": 1, "b": 2}`},
+		{"merge first source wins", merge, `{}`, []string{`{"a": 1}`, `{"a": 2}`}, `{"a": 1}`},
+		{"merge fills null", merge, `{"a": null}`, []string{`{"a": 2}`}, `{"a": 2}`},
+		{"merge is shallow", merge, `{"db": {"host": "x"}}`, []string{`{"db": {"host": "y", "port": 1}}`}, `{"db": {"host": "x"}}`},
+		{"merge nil dst", merge, `null`, []string{`{"a": 1}`}, `{"a": 1}`},
+		{"mergeOverwrite later wins", mergeOverwrite, `{"a": 1}`, []string{`{"a": 2}`, `{"a": 3}`}, `{"a": 3}`},
+		{"mergeOverwrite is deep", mergeOverwrite, `{"db": {"host": "x", "user": "u"}}`, []string{`{"db": {"host": "y", "port": 1}}`}, `{"db": {"host": "y", "port": 1, "user": "u"}}`},
+		{"mergeOverwrite skips null", mergeOverwrite, `{"a": 1}`, []string{`{"a": null}`}, `{"a": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst interface{}
+			if err := json.Unmarshal([]byte(tt.dst), &dst); err != nil {
+				t.Fatal(err)
+			}
+			srcs := make([]interface{}, len(tt.srcs))
+			for i, src := range tt.srcs {
+				srcs[i] = decodeMap(t, src)
+			}
+			before := deepCopy(dst)
+			beforeSrcs := deepCopy(srcs)
+
+			got, err := tt.fn(dst, srcs...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := decodeMap(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(dst, before) || !reflect.DeepEqual(srcs, beforeSrcs) {
+				t.Errorf("arguments were modified: dst %v, srcs %v", dst, srcs)
+			}
+		})
+	}
+}
+
+// 合并结果不会回写到模板数据中
+func TestMergeDoesNotMutateData(t *testing.T) {
+	result := renderGoTemplate(`{{ $m := merge .a .b }}{{ $o := mergeOverwrite .a .b }}{{ .a.x.k }} {{ len .a.x }} {{ len .a }}`, `{"a": {"x": {"k": 1}}, "b": {"x": {"k": 2, "j": 3}, "y": 4}}`, renderOptions{})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if result.Output != "1 1 1" {
+		t.Errorf("got %s, want the original data", result.Output)
+	}
+}
+
+func TestMergeRejectsNonMaps(t *testing.T) {
+	if _, err := merge(map[string]interface{}{}, []interface{}{1}); err == nil {
+		t.Error("merge with a list argument should fail")
+	}
+	if _, err := mergeOverwrite("x"); err == nil {
+		t.Error("mergeOverwrite with a string dst should fail")
+	}
+}
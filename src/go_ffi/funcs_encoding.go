@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// toQuery 将 map 编码为 application/x-www-form-urlencoded 格式（a=1&b=2）。
+// 键按字典序排列，列表值会展开为重复的键，nil 值编码为空字符串。
+// 嵌套的 map 或列表中的列表没有统一的编码约定，因此返回错误而不是猜测格式。
+func toQuery(v interface{}) (string, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("expected a map, got %T", v)
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values := url.Values{}
+	for _, key := range keys {
+		switch val := m[key].(type) {
+		case map[string]interface{}:
+			return "", fmt.Errorf("nested map under key %q is not supported", key)
+		case []interface{}, []string:
+			items, _ := toList(val)
+			for _, item := range items {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}, []string:
+					return "", fmt.Errorf("nested value in list under key %q is not supported", key)
+				}
+				values.Add(key, toString(item))
+			}
+		default:
+			values.Add(key, toString(val))
+		}
+	}
+	return values.Encode(), nil
+}
+
+// fromJSON 将 JSON 字符串解析为 map、列表或标量，便于处理被二次编码的字段。
+// 解析失败时返回带行列位置的执行错误。
+func fromJSON(s string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			// Offset 指向出错字符之后，减一得到出错字符本身的位置
+			line, col := offsetPosition(s, syntaxErr.Offset-1)
+			return nil, fmt.Errorf("invalid JSON at line %d, column %d: %v", line, col, err)
+		}
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return v, nil
+}
+
+// fromYAML 将 YAML 字符串解析为 map、列表或标量。
+// 非字符串键的 map 会转换为字符串键，以便在模板中通过 .key 访问。
+// yaml 的错误信息本身已经包含行号。
+func fromYAML(s string) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	return normalizeYAML(v), nil
+}
+
+// normalizeYAML 递归地将 map[interface{}]interface{} 转换为 map[string]interface{}
+func normalizeYAML(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, e := range val {
+			val[k] = normalizeYAML(e)
+		}
+		return val
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			m[toString(k)] = normalizeYAML(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range val {
+			val[i] = normalizeYAML(e)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// offsetPosition 将字节偏移量转换为从 1 开始的行号和列号（按字节计算列号）
+func offsetPosition(s string, offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(s)) {
+		offset = int64(len(s))
+	}
+	prefix := []byte(s[:offset])
+	line := bytes.Count(prefix, []byte("\n")) + 1
+	col := len(prefix) - bytes.LastIndexByte(prefix, '\n')
+	return line, col
+}
+
+// hexEnc 将字符串或字节切片（例如 toBytes 的结果）编码为小写十六进制，其他值先转换为字符串
+func hexEnc(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		return hex.EncodeToString(b)
+	}
+	return hex.EncodeToString([]byte(toString(v)))
+}
+
+// hexDec 将十六进制字符串（大小写均可）解码为字符串，输入无效时返回执行错误
+func hexDec(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid hex: %v", err)
+	}
+	return string(b), nil
+}
+
+// toBytes 将按 encoding（hex、base64 或 base64url）编码的字符串解码为字节切片，
+// 内置的 len 和 slice 因此按解码后的字节计算，结果可以再交给 hexEnc 输出。
+// 用法：{{ hexEnc (slice (toBytes "base64" .key) 0 4) }}
+func toBytes(encoding string, s string) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	switch encoding {
+	case "hex":
+		b, err = hex.DecodeString(s)
+	case "base64":
+		b, err = base64.StdEncoding.DecodeString(s)
+	case "base64url":
+		b, err = base64.URLEncoding.DecodeString(s)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q, expected hex, base64 or base64url", encoding)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", encoding, err)
+	}
+	return b, nil
+}
+
+// shellQuote 将值用单引号包裹，使其在 POSIX shell 中作为单个字面量参数。
+// 单引号内的 $、`、\ 和双引号都不会被解释；内嵌的单引号会先结束引号，转义后再重新开始引号。
+// 开启 escapeHtml 时 html/template 会把单引号转义为 &#39;，结果不再是有效的 shell 引用，
+// 因此生成 shell 脚本时应使用 text/template。
+func shellQuote(v interface{}) string {
+	return "'" + strings.ReplaceAll(toString(v), "'", `'\''`) + "'"
+}
+
+// quoteList 对列表中的每个元素调用 shellQuote，并用空格连接，nil 元素会被跳过
+func quoteList(v interface{}) string {
+	items := toStringSlice(v)
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = shellQuote(item)
+	}
+	return strings.Join(quoted, " ")
+}
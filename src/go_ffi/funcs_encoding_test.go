@@ -0,0 +1,195 @@
+package main
+
+import (
+	"testing"
+)
+
+// shellQuote 的结果在 POSIX shell 中是单个字面量参数，内嵌的单引号被转义，其他字符原样保留
+func TestShellQuote(t *testing.T) {
+	runTemplateCases(t, `{"quote": "it's", "slash": "a\\b", "dollar": "$HOME", "tick": "`+"`id`"+`", "empty": "", "null": null, "list": ["a b", null, "it's", "", 1000000], "none": []}`, []templateCase{
+		{"plain", `{{ shellQuote "abc" }}`, `'abc'`, false},
+		{"single quote", `{{ shellQuote .quote }}`, `'it'\''s'`, false},
+		{"only quotes", `{{ shellQuote "''" }}`, `''\'''\'''`, false},
+		{"backslash", `{{ shellQuote .slash }}`, `'a\b'`, false},
+		{"dollar", `{{ shellQuote .dollar }}`, `'$HOME'`, false},
+		{"backtick", `{{ shellQuote .tick }}`, "'`id`'", false},
+		{"double quote", `{{ shellQuote "say \"hi\"" }}`, `'say "hi"'`, false},
+		{"empty", `{{ shellQuote .empty }}`, `''`, false},
+		{"null", `{{ shellQuote .null }}`, `''`, false},
+		{"number", `{{ shellQuote 3 }}`, `'3'`, false},
+		{"list", `{{ quoteList .list }}`, `'a b' 'it'\''s' '' '1000000'`, false},
+		{"empty list", `{{ quoteList .none }}`, ``, false},
+		{"null list", `{{ quoteList .null }}`, ``, false},
+	})
+}
+
+// html/template 会把结果中的单引号转义为 &#39;，因此 shellQuote 只适用于 escapeHtml 关闭时
+func TestShellQuoteEscapeHtml(t *testing.T) {
+	result := renderGoTemplate(`{{ shellQuote "it's" }}`, `{}`, renderOptions{EscapeHtml: true})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if want := `&#39;it&#39;\&#39;&#39;s&#39;`; result.Output != want {
+		t.Errorf("got %q, want %q", result.Output, want)
+	}
+}
+
+// toQuery 按键排序编码，列表展开为重复的键，nil 编码为空值，嵌套的 map 和列表中的复合值被拒绝
+func TestToQuery(t *testing.T) {
+	data := `{
+		"simple": {"b": 2, "a": "x y"},
+		"list": {"tag": ["b", "a", null], "n": 1000000},
+		"escape": {"q": "a&b=c", "k y": "ü"},
+		"nil": {"a": null},
+		"empty": {},
+		"nestedMap": {"a": {"b": 1}},
+		"nestedList": {"a": [[1]]},
+		"mapInList": {"a": [{"b": 1}]}
+	}`
+	runTemplateCases(t, data, []templateCase{
+		{"sorted keys", `{{ toQuery .simple }}`, "a=x+y&b=2", false},
+		{"list values", `{{ toQuery .list }}`, "n=1000000&tag=b&tag=a&tag=", false},
+		{"escaping", `{{ toQuery .escape }}`, "k+y=%C3%BC&q=a%26b%3Dc", false},
+		{"nil value", `{{ toQuery .nil }}`, "a=", false},
+		{"empty map", `[{{ toQuery .empty }}]`, "[]", false},
+		{"nested map", `{{ toQuery .nestedMap }}`, "", true},
+		{"nested list", `{{ toQuery .nestedList }}`, "", true},
+		{"map in list", `{{ toQuery .mapInList }}`, "", true},
+		{"not a map", `{{ toQuery "a=1" }}`, "", true},
+		{"null", `{{ toQuery .missing }}`, "", true},
+	})
+}
+
+// toQuery 的错误信息指出出错的键
+func TestToQueryErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  string
+	}{
+		{"not a map", []interface{}{"a"}, "expected a map, got []interface {}"},
+		{"nested map", map[string]interface{}{"a": map[string]interface{}{}}, `nested map under key "a" is not supported`},
+		{"nested list", map[string]interface{}{"z": []interface{}{"ok", []string{"x"}}}, `nested value in list under key "z" is not supported`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := toQuery(tt.input); err == nil || err.Error() != tt.want {
+				t.Errorf("toQuery(%v) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+// fromJSON 和 fromYAML 解析出 map、列表或标量；YAML 的非字符串键被转换为字符串键
+func TestFromJSONYAML(t *testing.T) {
+	data := `{"json": "{\"a\": {\"b\": [1, 2]}, \"n\": 1000000}", "yaml": "a:\n  b: [1, 2]\n1: one\ntrue: yes\n", "list": "[\"x\", 1]", "bad": "{\"a\": }"}`
+	runTemplateCases(t, data, []templateCase{
+		{"json field", `{{ (fromJSON .json).a.b }}`, "[1 2]", false},
+		{"json number", `{{ join "," (fromJSON .json).n }}`, "1000000", false},
+		{"json list", `{{ index (fromJSON .list) 0 }}`, "x", false},
+		{"json scalar", `{{ fromJSON "true" }}`, "true", false},
+		{"json null", `{{ if fromJSON "null" }}set{{ else }}unset{{ end }}`, "unset", false},
+		{"json invalid", `{{ fromJSON .bad }}`, "", true},
+		{"json trailing data", `{{ fromJSON "1 2" }}`, "", true},
+		{"json empty", `{{ fromJSON "" }}`, "", true},
+		{"yaml field", `{{ (fromYAML .yaml).a.b }}`, "[1 2]", false},
+		{"yaml int key", `{{ index (fromYAML .yaml) "1" }}`, "one", false},
+		{"yaml bool key", `{{ index (fromYAML .yaml) "true" }}`, "yes", false},
+		{"yaml nested int key", `{{ index (index (fromYAML "a:\n  2: two\n") "a") "2" }}`, "two", false},
+		{"yaml list of maps", `{{ index (index (fromYAML "- 3: x\n") 0) "3" }}`, "x", false},
+		{"yaml scalar", `{{ fromYAML "hello" }}`, "hello", false},
+		{"yaml invalid", `{{ fromYAML "a: [1" }}`, "", true},
+	})
+}
+
+// JSON 语法错误报告出错字符所在的行列，输入不完整时指向最后一个字符
+func TestFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"first line", `{"a": x}`, "invalid JSON at line 1, column 7: invalid character 'x' looking for beginning of value"},
+		{"later line", "{\n  \"a\": 1,\n  \"b\": ]\n}", "invalid JSON at line 3, column 8: invalid character ']' looking for beginning of value"},
+		{"first character", `]`, "invalid JSON at line 1, column 1: invalid character ']' looking for beginning of value"},
+		{"after newline", "[1,\n}", "invalid JSON at line 2, column 1: invalid character '}' looking for beginning of value"},
+		{"truncated", "{\"a\":\n 1", "invalid JSON at line 2, column 2: unexpected end of JSON input"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := fromJSON(tt.input); err == nil || err.Error() != tt.want {
+				t.Errorf("fromJSON(%q) error = %v, want %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+// offsetPosition 返回从 1 开始的行列号，列号按字节计算，越界的偏移量被截到字符串范围内
+func TestOffsetPosition(t *testing.T) {
+	tests := []struct {
+		s      string
+		offset int64
+		line   int
+		col    int
+	}{
+		{"abc", 0, 1, 1},
+		{"abc", 2, 1, 3},
+		{"ab\ncd", 2, 1, 3},
+		{"ab\ncd", 3, 2, 1},
+		{"ab\ncd", 4, 2, 2},
+		{"a\n\nb", 3, 3, 1},
+		{"ü\nx", 1, 1, 2},
+		{"ü\nx", 3, 2, 1},
+		{"", 0, 1, 1},
+		{"abc", -1, 1, 1},
+		{"ab\ncd", 99, 2, 3},
+	}
+	for _, tt := range tests {
+		line, col := offsetPosition(tt.s, tt.offset)
+		if line != tt.line || col != tt.col {
+			t.Errorf("offsetPosition(%q, %d) = %d:%d, want %d:%d", tt.s, tt.offset, line, col, tt.line, tt.col)
+		}
+	}
+}
+
+// hexEnc 输出小写十六进制，hexDec 接受大小写并拒绝无效输入；toBytes 的结果按字节参与 len 和 slice
+func TestHexAndBytes(t *testing.T) {
+	data := `{"s": "hé", "n": 255, "b64": "AAECAwQF", "b64url": "-_8=", "none": null}`
+	runTemplateCases(t, data, []templateCase{
+		{"hexEnc string", `{{ hexEnc .s }}`, "68c3a9", false},
+		{"hexEnc number", `{{ hexEnc .n }}`, "323535", false},
+		{"hexEnc nil", `[{{ hexEnc .none }}]`, "[]", false},
+		{"hexEnc bytes", `{{ hexEnc (toBytes "base64" .b64) }}`, "000102030405", false},
+		{"hexDec", `{{ hexDec "68c3a9" }}`, "hé", false},
+		{"hexDec upper", `{{ hexDec "4A4B" }}`, "JK", false},
+		{"hexDec empty", `[{{ hexDec "" }}]`, "[]", false},
+		{"hexDec round trip", `{{ hexDec (hexEnc .s) }}`, "hé", false},
+		{"hexDec odd length", `{{ hexDec "abc" }}`, "", true},
+		{"hexDec invalid", `{{ hexDec "zz" }}`, "", true},
+		{"toBytes len", `{{ len (toBytes "base64" .b64) }}`, "6", false},
+		{"toBytes slice", `{{ hexEnc (slice (toBytes "base64" .b64) 1 3) }}`, "0102", false},
+		{"toBytes hex", `{{ len (toBytes "hex" "00ff10") }}`, "3", false},
+		{"toBytes base64url", `{{ hexEnc (toBytes "base64url" .b64url) }}`, "fbff", false},
+		{"toBytes base64 rejects url alphabet", `{{ toBytes "base64" .b64url }}`, "", true},
+		{"toBytes invalid hex", `{{ toBytes "hex" "0g" }}`, "", true},
+		{"toBytes unknown encoding", `{{ toBytes "base32" "AA" }}`, "", true},
+	})
+}
+
+// toBytes 的错误信息包含编码名称
+func TestToBytesErrors(t *testing.T) {
+	tests := []struct {
+		encoding string
+		input    string
+		want     string
+	}{
+		{"hex", "0g", "invalid hex: encoding/hex: invalid byte: U+0067 'g'"},
+		{"base64", "A", "invalid base64: illegal base64 data at input byte 0"},
+		{"base32", "AA", `unknown encoding "base32", expected hex, base64 or base64url`},
+	}
+	for _, tt := range tests {
+		if _, err := toBytes(tt.encoding, tt.input); err == nil || err.Error() != tt.want {
+			t.Errorf("toBytes(%q, %q) error = %v, want %q", tt.encoding, tt.input, err, tt.want)
+		}
+	}
+}
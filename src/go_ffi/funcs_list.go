@@ -0,0 +1,176 @@
+package main
+
+// at 按下标安全地获取列表元素，越界时返回 nil 而不是报错。
+// 负数下标从末尾开始计数，-1 表示最后一个元素。
+func at(list interface{}, index interface{}) (interface{}, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	i, err := toInt(index)
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 {
+		i += len(items)
+	}
+	if i < 0 || i >= len(items) {
+		return nil, nil
+	}
+	return items[i], nil
+}
+
+// first 返回列表的第一个元素，空列表返回 nil
+func first(list interface{}) (interface{}, error) {
+	return at(list, 0)
+}
+
+// last 返回列表的最后一个元素，空列表返回 nil
+func last(list interface{}) (interface{}, error) {
+	return at(list, -1)
+}
+
+// rest 返回除第一个元素外的其余元素，空列表返回空列表
+func rest(list interface{}) ([]interface{}, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return []interface{}{}, nil
+	}
+	return items[1:], nil
+}
+
+// elementKey 是集合运算中用于比较列表元素的键。
+// 数字按数值比较（1 与 1.0 相等），字符串 "1" 与数字 1 不相等，
+// map 和列表按 JSON 编码后的内容比较。
+type elementKey struct {
+	kind  string
+	value interface{}
+}
+
+func toElementKey(v interface{}) (elementKey, error) {
+	switch val := v.(type) {
+	case nil:
+		return elementKey{kind: "nil"}, nil
+	case string:
+		return elementKey{kind: "string", value: val}, nil
+	case bool:
+		return elementKey{kind: "bool", value: val}, nil
+	}
+	if n, err := toNumber(v); err == nil {
+		return elementKey{kind: "number", value: n.value()}, nil
+	}
+	encoded, err := encodeJSON(v)
+	if err != nil {
+		return elementKey{}, err
+	}
+	return elementKey{kind: "json", value: encoded}, nil
+}
+
+// keySet 返回列表中所有元素的键集合
+func keySet(list interface{}) (map[elementKey]bool, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[elementKey]bool, len(items))
+	for _, item := range items {
+		key, err := toElementKey(item)
+		if err != nil {
+			return nil, err
+		}
+		set[key] = true
+	}
+	return set, nil
+}
+
+// appendUnique 将 items 中满足 keep 且尚未出现过的元素按原顺序追加到 out
+func appendUnique(out []interface{}, seen map[elementKey]bool, items []interface{}, keep func(elementKey) bool) ([]interface{}, error) {
+	for _, item := range items {
+		key, err := toElementKey(item)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] || !keep(key) {
+			continue
+		}
+		seen[key] = true
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// uniq 去掉列表中重复的元素，保留每个元素第一次出现的位置
+func uniq(list interface{}) ([]interface{}, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	keepAll := func(elementKey) bool { return true }
+	return appendUnique([]interface{}{}, map[elementKey]bool{}, items, keepAll)
+}
+
+// union 返回所有列表元素的并集，按列表和元素的出现顺序排列，重复元素只保留第一次出现的
+func union(lists ...interface{}) ([]interface{}, error) {
+	out := []interface{}{}
+	seen := map[elementKey]bool{}
+	keepAll := func(elementKey) bool { return true }
+	for _, list := range lists {
+		items, err := toList(list)
+		if err != nil {
+			return nil, err
+		}
+		if out, err = appendUnique(out, seen, items, keepAll); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// intersection 返回 list 中同时出现在其余每个列表中的元素，保持 list 中的顺序并去重
+func intersection(list interface{}, others ...interface{}) ([]interface{}, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]map[elementKey]bool, len(others))
+	for i, other := range others {
+		if sets[i], err = keySet(other); err != nil {
+			return nil, err
+		}
+	}
+	inAll := func(key elementKey) bool {
+		for _, set := range sets {
+			if !set[key] {
+				return false
+			}
+		}
+		return true
+	}
+	return appendUnique([]interface{}{}, map[elementKey]bool{}, items, inAll)
+}
+
+// difference 返回 list 中不在其余任何列表中出现的元素，保持 list 中的顺序并去重
+func difference(list interface{}, others ...interface{}) ([]interface{}, error) {
+	items, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	sets := make([]map[elementKey]bool, len(others))
+	for i, other := range others {
+		if sets[i], err = keySet(other); err != nil {
+			return nil, err
+		}
+	}
+	inNone := func(key elementKey) bool {
+		for _, set := range sets {
+			if set[key] {
+				return false
+			}
+		}
+		return true
+	}
+	return appendUnique([]interface{}{}, map[elementKey]bool{}, items, inNone)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// at 支持负数下标，越界时返回 nil；first、last 和 rest 在空列表上不会出错
+func TestListAccess(t *testing.T) {
+	list := []interface{}{"a", "b", "c"}
+	tests := []struct {
+		name string
+		fn   func() (interface{}, error)
+		want interface{}
+	}{
+		{"at 0", func() (interface{}, error) { return at(list, 0) }, "a"},
+		{"at 2", func() (interface{}, error) { return at(list, 2) }, "c"},
+		{"at -1", func() (interface{}, error) { return at(list, -1) }, "c"},
+		{"at -3", func() (interface{}, error) { return at(list, -3) }, "a"},
+		{"at -4", func() (interface{}, error) { return at(list, -4) }, nil},
+		{"at 3", func() (interface{}, error) { return at(list, 3) }, nil},
+		{"at float index", func() (interface{}, error) { return at(list, 1.0) }, "b"},
+		{"at typed slice", func() (interface{}, error) { return at([]string{"x", "y"}, -1) }, "y"},
+		{"at nil", func() (interface{}, error) { return at(nil, 0) }, nil},
+		{"first", func() (interface{}, error) { return first(list) }, "a"},
+		{"first empty", func() (interface{}, error) { return first([]interface{}{}) }, nil},
+		{"last", func() (interface{}, error) { return last(list) }, "c"},
+		{"last empty", func() (interface{}, error) { return last([]interface{}{}) }, nil},
+		{"rest", func() (interface{}, error) { return rest(list) }, []interface{}{"b", "c"}},
+		{"rest single", func() (interface{}, error) { return rest([]interface{}{"a"}) }, []interface{}{}},
+		{"rest empty", func() (interface{}, error) { return rest(nil) }, []interface{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListAccessErrors(t *testing.T) {
+	if _, err := at("abc", 0); err == nil {
+		t.Error("at on a string should fail")
+	}
+	if _, err := at([]interface{}{1}, "x"); err == nil {
+		t.Error("at with a non-integer index should fail")
+	}
+	if _, err := rest(map[string]interface{}{}); err == nil {
+		t.Error("rest on a map should fail")
+	}
+	runTemplateCases(t, `{"items": [1, 2, 3]}`, []templateCase{
+		{"at in template", `{{ at .items -1 }}`, "3", false},
+		{"out of range in template", `{{ at .items 10 }}`, "<no value>", false},
+		{"range rest", `{{ range rest .items }}{{ . }}{{ end }}`, "23", false},
+	})
+}
+
+// 集合运算保持第一个列表中的顺序和元素第一次出现的位置，数字按数值比较，字符串与数字不相等
+func TestSetOperations(t *testing.T) {
+	a := []interface{}{3.0, "x", 1.0, 3, "1", map[string]interface{}{"k": 1.0}}
+	b := []interface{}{1, "x", map[string]interface{}{"k": 1}}
+	c := []interface{}{"x", 2}
+	tests := []struct {
+		name string
+		fn   func() ([]interface{}, error)
+		want []interface{}
+	}{
+		{"uniq", func() ([]interface{}, error) { return uniq(a) }, []interface{}{3.0, "x", 1.0, "1", map[string]interface{}{"k": 1.0}}},
+		{"uniq keeps first occurrence", func() ([]interface{}, error) { return uniq([]interface{}{"b", "a", "b", "c", "a"}) }, []interface{}{"b", "a", "c"}},
+		{"uniq empty", func() ([]interface{}, error) { return uniq(nil) }, []interface{}{}},
+		{"union", func() ([]interface{}, error) { return union(c, b) }, []interface{}{"x", 2, 1, map[string]interface{}{"k": 1}}},
+		{"union none", func() ([]interface{}, error) { return union() }, []interface{}{}},
+		{"intersection", func() ([]interface{}, error) { return intersection(a, b) }, []interface{}{"x", 1.0, map[string]interface{}{"k": 1.0}}},
+		{"intersection of three", func() ([]interface{}, error) { return intersection(a, b, c) }, []interface{}{"x"}},
+		{"intersection alone", func() ([]interface{}, error) { return intersection([]interface{}{2, 1, 2}) }, []interface{}{2, 1}},
+		{"difference", func() ([]interface{}, error) { return difference(a, b) }, []interface{}{3.0, "1"}},
+		{"difference of three", func() ([]interface{}, error) {
+			return difference([]interface{}{1, 2, 3, 2}, []interface{}{1}, []interface{}{3})
+		}, []interface{}{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetOperationErrors(t *testing.T) {
+	if _, err := uniq("abc"); err == nil {
+		t.Error("uniq on a string should fail")
+	}
+	if _, err := union([]interface{}{1}, 2); err == nil {
+		t.Error("union with a non-list argument should fail")
+	}
+	if _, err := difference([]interface{}{1}, map[string]interface{}{}); err == nil {
+		t.Error("difference with a map argument should fail")
+	}
+}
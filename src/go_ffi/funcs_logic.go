@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	texttemplate "text/template"
+)
+
+// ifNil 仅在 value 为 nil（键不存在或 JSON null）时返回 fallback。
+// 与 Sprig 的 default 不同，0、false、"" 和空列表等零值会被原样保留，
+// 适用于 0 或 false 本身就有意义的数值、布尔配置。
+// fallback 在前，用法为 ifNil fallback value，便于在管道中使用：{{ .replicas | ifNil 1 }}
+func ifNil(fallback interface{}, value interface{}) interface{} {
+	if value == nil {
+		return fallback
+	}
+	return value
+}
+
+// coalesceNil 返回第一个不为 nil 的参数，全部为 nil 时返回 nil。
+// 与 ifNil 相同，零值不会被跳过。
+func coalesceNil(values ...interface{}) interface{} {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// required 在 v 为 nil（缺失的键或 JSON null）或空字符串时以 msg 作为错误中止渲染，否则原样返回 v。
+// 与 Sprig 一致，0、false 和空列表被视为有效值。
+func required(msg string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+// getOr 从最后一个参数（根值）开始，按路径逐级访问 map 的键和列表的下标，
+// 任何一级缺失、为 nil 或类型不匹配时返回 fallback，而不是报错。
+// 对 map 而言路径段会转换为字符串（0 访问键 "0"）；对列表而言路径段必须是整数或整数形式的字符串，
+// 负数下标从末尾开始计数，与 at 相同。用法：getOr "n/a" "items" 0 "name" .
+func getOr(fallback interface{}, args ...interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing root value after the path")
+	}
+	current := args[len(args)-1]
+	for _, segment := range args[:len(args)-1] {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current = node[toString(segment)]
+		case nil:
+			return fallback, nil
+		default:
+			index, err := toInt(segment)
+			if err != nil {
+				return fallback, nil
+			}
+			if current, err = at(node, index); err != nil {
+				return fallback, nil
+			}
+		}
+	}
+	if current == nil {
+		return fallback, nil
+	}
+	return current, nil
+}
+
+// fail 以 msg 中止渲染，与 Sprig 的 fail 相同，用于在模板中检查不变量
+func fail(msg string) (string, error) {
+	return "", templateFailure{msg: msg}
+}
+
+// failIf 在 cond 为真（按 Go 模板 if 的规则）时以 msg 中止渲染，否则不输出任何内容。
+// 用法：failIf (gt .replicas 100) "too many replicas"
+func failIf(cond interface{}, msg string) (string, error) {
+	if truth, _ := texttemplate.IsTrue(cond); truth {
+		return fail(msg)
+	}
+	return "", nil
+}
+
+// isFlagSet 按 Go 模板 if 的规则判断标志是否开启，缺失的键和 JSON null 为 false
+func isFlagSet(flag interface{}) bool {
+	truth, _ := texttemplate.IsTrue(flag)
+	return truth
+}
+
+// allOf 在所有标志都开启时返回 true，没有参数时为 true。
+// 与内置的 and 不同，总是返回布尔值，便于组合：if allOf .featureA (anyOf .beta .internal)
+func allOf(flags ...interface{}) bool {
+	for _, flag := range flags {
+		if !isFlagSet(flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyOf 在至少一个标志开启时返回 true，没有参数时为 false
+func anyOf(flags ...interface{}) bool {
+	for _, flag := range flags {
+		if isFlagSet(flag) {
+			return true
+		}
+	}
+	return false
+}
+
+// noneOf 在所有标志都未开启时返回 true，没有参数时为 true
+func noneOf(flags ...interface{}) bool {
+	return !anyOf(flags...)
+}
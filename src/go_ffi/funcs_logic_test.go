@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+
+// ifNil 与 coalesceNil 只跳过 nil（缺失的键和 JSON null），0、false、"" 和空列表都会被保留
+func TestIfNilCoalesceNil(t *testing.T) {
+	data := `{"zero": 0, "no": false, "empty": "", "list": [], "none": null, "name": "x"}`
+	runTemplateCases(t, data, []templateCase{
+		{"ifNil zero", `{{ ifNil 5 .zero }}`, "0", false},
+		{"ifNil false", `{{ ifNil true .no }}`, "false", false},
+		{"ifNil empty string", `[{{ ifNil "d" .empty }}]`, "[]", false},
+		{"ifNil empty list", `{{ ifNil "d" .list }}`, "[]", false},
+		{"ifNil null", `{{ ifNil 5 .none }}`, "5", false},
+		{"ifNil missing", `{{ ifNil 5 .missing }}`, "5", false},
+		{"ifNil value", `{{ ifNil "d" .name }}`, "x", false},
+		{"ifNil pipeline", `{{ .none | ifNil "d" }}`, "d", false},
+		{"ifNil pipeline zero", `{{ .zero | ifNil 5 }}`, "0", false},
+		{"coalesceNil zero", `{{ coalesceNil .missing .none .zero 5 }}`, "0", false},
+		{"coalesceNil false", `{{ coalesceNil .none .no true }}`, "false", false},
+		{"coalesceNil empty string", `[{{ coalesceNil .missing .empty "d" }}]`, "[]", false},
+		{"coalesceNil fallback", `{{ coalesceNil .missing .none "d" }}`, "d", false},
+		{"coalesceNil all nil", `{{ if coalesceNil .missing .none }}set{{ else }}unset{{ end }}`, "unset", false},
+		{"coalesceNil no arguments", `{{ if coalesceNil }}set{{ else }}unset{{ end }}`, "unset", false},
+	})
+}
+
+// getOr 沿 map 键和列表下标逐级访问，任何一级缺失、为 nil 或类型不匹配时返回 fallback；缺少根值时报错
+func TestGetOr(t *testing.T) {
+	data := `{"items": [{"name": "a", "tags": ["x", "y"]}, {"name": "b"}], "byId": {"0": "zero", "7": "seven"}, "count": 0, "none": null, "name": "str"}`
+	runTemplateCases(t, data, []templateCase{
+		{"map and list", `{{ getOr "n/a" "items" 0 "name" . }}`, "a", false},
+		{"nested list", `{{ getOr "n/a" "items" 0 "tags" 1 . }}`, "y", false},
+		{"string index", `{{ getOr "n/a" "items" "1" "name" . }}`, "b", false},
+		{"negative index", `{{ getOr "n/a" "items" -1 "name" . }}`, "b", false},
+		{"negative nested", `{{ getOr "n/a" "items" 0 "tags" -2 . }}`, "x", false},
+		{"numeric map key", `{{ getOr "n/a" "byId" 7 . }}`, "seven", false},
+		{"zero map key", `{{ getOr "n/a" "byId" 0 . }}`, "zero", false},
+		{"zero value kept", `{{ getOr 5 "count" . }}`, "0", false},
+		{"empty path", `{{ getOr "n/a" .name }}`, "str", false},
+		{"missing key", `{{ getOr "n/a" "items" 0 "missing" . }}`, "n/a", false},
+		{"index out of range", `{{ getOr "n/a" "items" 5 "name" . }}`, "n/a", false},
+		{"negative out of range", `{{ getOr "n/a" "items" -3 "name" . }}`, "n/a", false},
+		{"null step", `{{ getOr "n/a" "none" "x" . }}`, "n/a", false},
+		{"null leaf", `{{ getOr "n/a" "none" . }}`, "n/a", false},
+		{"missing root key", `{{ getOr "n/a" "x" .missing }}`, "n/a", false},
+		{"non-integer list index", `{{ getOr "n/a" "items" "first" . }}`, "n/a", false},
+		{"index into string", `{{ getOr "n/a" "name" 0 . }}`, "n/a", false},
+		{"key into number", `{{ getOr "n/a" "count" "x" . }}`, "n/a", false},
+		{"key into list", `{{ getOr "n/a" "items" "name" . }}`, "n/a", false},
+		{"missing root", `{{ getOr "n/a" }}`, "", true},
+	})
+}
+
+// 缺少根值的错误信息提示路径后需要跟根值
+func TestGetOrMissingRoot(t *testing.T) {
+	if _, err := getOr("n/a"); err == nil || err.Error() != "missing root value after the path" {
+		t.Errorf("getOr without a root returned %v", err)
+	}
+}
+
+// allOf、anyOf 和 noneOf 按 if 的规则判断标志：缺失的键、null、0、false、"" 和空集合都视为未开启
+func TestFlagHelpers(t *testing.T) {
+	data := `{"on": true, "off": false, "one": 1, "zero": 0, "s": "x", "empty": "", "list": [0], "noList": [], "map": {"a": 1}, "noMap": {}, "none": null}`
+	runTemplateCases(t, data, []templateCase{
+		{"allOf set", `{{ allOf .on .one .s .list .map }}`, "true", false},
+		{"allOf one unset", `{{ allOf .on .one .zero }}`, "false", false},
+		{"allOf missing", `{{ allOf .on .missing }}`, "false", false},
+		{"allOf null", `{{ allOf .on .none }}`, "false", false},
+		{"allOf no arguments", `{{ allOf }}`, "true", false},
+		{"anyOf one set", `{{ anyOf .off .zero .s }}`, "true", false},
+		{"anyOf none set", `{{ anyOf .off .zero .empty .noList .noMap .none .missing }}`, "false", false},
+		{"anyOf no arguments", `{{ anyOf }}`, "false", false},
+		{"noneOf none set", `{{ noneOf .off .empty .missing }}`, "true", false},
+		{"noneOf one set", `{{ noneOf .off .on }}`, "false", false},
+		{"noneOf no arguments", `{{ noneOf }}`, "true", false},
+		{"nested", `{{ if allOf .on (anyOf .off .s) (noneOf .zero) }}yes{{ else }}no{{ end }}`, "yes", false},
+		{"nested unset", `{{ if allOf .on (anyOf .off .empty) }}yes{{ else }}no{{ end }}`, "no", false},
+		{"boolean result", `{{ printf "%T" (anyOf .s) }}`, "bool", false},
+		{"compared with and", `{{ and .on .s }} {{ allOf .on .s }}`, "x true", false},
+	})
+}
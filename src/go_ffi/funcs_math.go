@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// 数学辅助函数的类型提升规则：
+//   - 所有操作数都是整数时结果为整数（int64），否则结果为 float64；
+//   - 没有小数部分的 float64（例如 JSON 中的 3）视为整数；
+//   - 因此 div 在两个操作数都是整数时执行整数除法，div 7 2 得到 3，div 7.0 2 同样得到 3，
+//     需要小数结果时请传入带小数部分的值，例如 div 7 2.5。
+//   - 除数为 0 时返回执行错误，而不是 panic。
+
+var errDivisionByZero = errors.New("division by zero")
+
+// add 计算所有参数之和
+func add(a interface{}, rest ...interface{}) (interface{}, error) {
+	return foldNumbers(a, rest, func(x, y int64) int64 { return x + y }, func(x, y float64) float64 { return x + y })
+}
+
+// sub 计算 a - b
+func sub(a interface{}, b interface{}) (interface{}, error) {
+	return foldNumbers(a, []interface{}{b}, func(x, y int64) int64 { return x - y }, func(x, y float64) float64 { return x - y })
+}
+
+// mul 计算所有参数之积
+func mul(a interface{}, rest ...interface{}) (interface{}, error) {
+	return foldNumbers(a, rest, func(x, y int64) int64 { return x * y }, func(x, y float64) float64 { return x * y })
+}
+
+// div 计算 a / b，两个整数相除时执行整数除法
+func div(a interface{}, b interface{}) (interface{}, error) {
+	x, y, err := numberPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if y.float() == 0 {
+		return nil, errDivisionByZero
+	}
+	if x.isInt && y.isInt {
+		return x.i / y.i, nil
+	}
+	return x.float() / y.float(), nil
+}
+
+// mod 计算 a 除以 b 的余数，结果符号与 a 相同
+func mod(a interface{}, b interface{}) (interface{}, error) {
+	x, y, err := numberPair(a, b)
+	if err != nil {
+		return nil, err
+	}
+	if y.float() == 0 {
+		return nil, errDivisionByZero
+	}
+	if x.isInt && y.isInt {
+		return x.i % y.i, nil
+	}
+	return math.Mod(x.float(), y.float()), nil
+}
+
+// scalarMax 返回参数中的最大值（模板中的 max）
+func scalarMax(a interface{}, rest ...interface{}) (interface{}, error) {
+	return pickNumber(a, rest, func(x, y float64) bool { return x > y })
+}
+
+// scalarMin 返回参数中的最小值（模板中的 min）
+func scalarMin(a interface{}, rest ...interface{}) (interface{}, error) {
+	return pickNumber(a, rest, func(x, y float64) bool { return x < y })
+}
+
+// round 四舍五入到 places 位小数（默认 0 位），结果总是 float64
+func round(v interface{}, places ...interface{}) (float64, error) {
+	n, err := toNumber(v)
+	if err != nil {
+		return 0, err
+	}
+	p := 0
+	if len(places) > 0 {
+		if p, err = toInt(places[0]); err != nil {
+			return 0, err
+		}
+	}
+	scale := math.Pow(10, float64(p))
+	return math.Round(n.float()*scale) / scale, nil
+}
+
+// percent 将 part / whole 格式化为百分比，保留 places 位小数（默认 0 位），舍入规则与 round 相同：
+// percent 1 8 为 "13%"，percent 1 8 1 为 "12.5%"。
+// whole 为 0 时返回 fallback（默认 "0%"）而不是报错，适用于数据可能为空的统计。
+// 用法：percent part whole [places] [fallback]
+func percent(part interface{}, whole interface{}, options ...interface{}) (string, error) {
+	x, y, err := numberPair(part, whole)
+	if err != nil {
+		return "", err
+	}
+	if len(options) > 2 {
+		return "", errors.New("expected at most places and fallback after part and whole")
+	}
+	places := 0
+	if len(options) > 0 {
+		if places, err = toInt(options[0]); err != nil {
+			return "", err
+		}
+		if places < 0 {
+			return "", errors.New("decimal places must not be negative")
+		}
+	}
+	if y.float() == 0 {
+		if len(options) > 1 {
+			return toString(options[1]), nil
+		}
+		return "0%", nil
+	}
+	value, err := round(x.float()/y.float()*100, places)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatFloat(value, 'f', places, 64) + "%", nil
+}
+
+// ratio 返回 a:b 约分后的比例，例如 ratio 1920 1080 为 "16:9"，参数必须是整数。
+// 两个参数都为 0 时比例没有意义，返回 fallback（默认 "0:0"）而不是报错。
+// 用法：ratio a b [fallback]
+func ratio(a interface{}, b interface{}, fallback ...interface{}) (string, error) {
+	x, err := toInt(a)
+	if err != nil {
+		return "", err
+	}
+	y, err := toInt(b)
+	if err != nil {
+		return "", err
+	}
+	if len(fallback) > 1 {
+		return "", errors.New("expected at most one fallback after a and b")
+	}
+	if x == 0 && y == 0 {
+		if len(fallback) > 0 {
+			return toString(fallback[0]), nil
+		}
+		return "0:0", nil
+	}
+	d := gcd(x, y)
+	return strconv.Itoa(x/d) + ":" + strconv.Itoa(y/d), nil
+}
+
+// gcd 返回两个不同时为 0 的整数的最大公约数（总是正数）
+func gcd(a int, b int) int {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// 统计函数作用于数值列表，缺失的键和 null 视为空列表，空列表返回 0 而不是报错，
+// 这样数据缺失时报表仍能渲染。
+
+// sum 返回列表中所有数值之和，全部为整数时结果为整数
+func sum(list interface{}) (interface{}, error) {
+	values, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	return foldNumbers(int64(0), values, func(x, y int64) int64 { return x + y }, func(x, y float64) float64 { return x + y })
+}
+
+// avg 返回列表的算术平均值，结果总是 float64
+func avg(list interface{}) (float64, error) {
+	numbers, err := listNumbers(list)
+	if err != nil || len(numbers) == 0 {
+		return 0, err
+	}
+	total := 0.0
+	for _, n := range numbers {
+		total += n.float()
+	}
+	return total / float64(len(numbers)), nil
+}
+
+// maxOf 返回列表中的最大值，保留其原始的整数/浮点类型
+func maxOf(list interface{}) (interface{}, error) {
+	return pickListNumber(list, func(x, y float64) bool { return x > y })
+}
+
+// minOf 返回列表中的最小值，保留其原始的整数/浮点类型
+func minOf(list interface{}) (interface{}, error) {
+	return pickListNumber(list, func(x, y float64) bool { return x < y })
+}
+
+// median 返回列表的中位数：长度为奇数时为中间的值，为偶数时为中间两个值的平均数
+func median(list interface{}) (interface{}, error) {
+	numbers, err := listNumbers(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(numbers) == 0 {
+		return int64(0), nil
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i].float() < numbers[j].float() })
+	mid := len(numbers) / 2
+	if len(numbers)%2 == 1 {
+		return numbers[mid].value(), nil
+	}
+	return (numbers[mid-1].float() + numbers[mid].float()) / 2, nil
+}
+
+// listNumbers 将列表中的每个元素转换为 number
+func listNumbers(list interface{}) ([]number, error) {
+	values, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	return toNumbers(values)
+}
+
+// pickListNumber 与 pickNumber 相同，但作用于列表，空列表返回 0
+func pickListNumber(list interface{}, better func(float64, float64) bool) (interface{}, error) {
+	values, err := toList(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return int64(0), nil
+	}
+	return pickNumber(values[0], values[1:], better)
+}
+
+func numberPair(a interface{}, b interface{}) (number, number, error) {
+	x, err := toNumber(a)
+	if err != nil {
+		return number{}, number{}, err
+	}
+	y, err := toNumber(b)
+	if err != nil {
+		return number{}, number{}, err
+	}
+	return x, y, nil
+}
+
+// foldNumbers 依次对参数执行二元运算，遇到非整数后改用浮点运算
+func foldNumbers(first interface{}, rest []interface{}, intOp func(int64, int64) int64, floatOp func(float64, float64) float64) (interface{}, error) {
+	acc, err := toNumber(first)
+	if err != nil {
+		return nil, err
+	}
+	others, err := toNumbers(rest)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range others {
+		if acc.isInt && n.isInt {
+			acc = number{i: intOp(acc.i, n.i), isInt: true}
+		} else {
+			acc = number{f: floatOp(acc.float(), n.float())}
+		}
+	}
+	return acc.value(), nil
+}
+
+// pickNumber 返回使 better 成立的那个参数，保留其原始的整数/浮点类型
+func pickNumber(first interface{}, rest []interface{}, better func(float64, float64) bool) (interface{}, error) {
+	best, err := toNumber(first)
+	if err != nil {
+		return nil, err
+	}
+	others, err := toNumbers(rest)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range others {
+		if better(n.float(), best.float()) {
+			best = n
+		}
+	}
+	return best.value(), nil
+}
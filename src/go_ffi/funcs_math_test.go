@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// templateCase 是辅助函数表驱动测试中的一项，wantErr 为 true 时只要求渲染失败
+type templateCase struct {
+	name     string
+	template string
+	want     string
+	wantErr  bool
+}
+
+// runTemplateCases 用同一份数据逐个渲染 tests 中的模板，并与期望的输出比较
+func runTemplateCases(t *testing.T, data string, tests []templateCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(tt.template, data, renderOptions{})
+			if tt.wantErr {
+				if result.Error == "" {
+					t.Errorf("got output %q, want an error", result.Output)
+				}
+				return
+			}
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// max/min 比较多个参数，maxOf/minOf 比较一个列表，两者都保留原始的数字类型
+func TestMaxMin(t *testing.T) {
+	runTemplateCases(t, `{"ints": [3, 9, 1], "mixed": [2, 7.5, 4], "empty": []}`, []templateCase{
+		{"max", `{{ max 3 9 1 }}`, "9", false},
+		{"min", `{{ min 3 9 1 }}`, "1", false},
+		{"max single", `{{ max 4 }}`, "4", false},
+		{"max float", `{{ max 1 2.5 }}`, "2.5", false},
+		{"maxOf", `{{ maxOf .ints }}`, "9", false},
+		{"minOf", `{{ minOf .ints }}`, "1", false},
+		{"maxOf mixed", `{{ maxOf .mixed }}`, "7.5", false},
+		{"maxOf empty", `{{ maxOf .empty }}`, "0", false},
+		{"minOf missing", `{{ minOf .nothing }}`, "0", false},
+		{"max non-number", `{{ max 1 "x" }}`, "", true},
+	})
+}
+
+// 算术函数在所有操作数都是整数时返回 int64，否则返回 float64；没有小数部分的 float64 视为整数
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() (interface{}, error)
+		want interface{}
+	}{
+		{"add ints", func() (interface{}, error) { return add(1, 2, 3) }, int64(6)},
+		{"add JSON integers", func() (interface{}, error) { return add(1.0, 2.0) }, int64(3)},
+		{"add float", func() (interface{}, error) { return add(1, 0.5) }, 1.5},
+		{"sub", func() (interface{}, error) { return sub(2, 5) }, int64(-3)},
+		{"mul promotes", func() (interface{}, error) { return mul(2, 3, 0.5) }, 3.0},
+		{"div integer", func() (interface{}, error) { return div(7, 2) }, int64(3)},
+		{"div integer float", func() (interface{}, error) { return div(7.0, 2) }, int64(3)},
+		{"div negative truncates", func() (interface{}, error) { return div(-7, 2) }, int64(-3)},
+		{"div fraction", func() (interface{}, error) { return div(7, 2.5) }, 2.8},
+		{"mod", func() (interface{}, error) { return mod(7, 3) }, int64(1)},
+		{"mod sign of dividend", func() (interface{}, error) { return mod(-7, 3) }, int64(-1)},
+		{"mod float", func() (interface{}, error) { return mod(7.5, 2) }, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
+
+// 除数为 0 时返回 errDivisionByZero，而不是 panic 或返回 Inf
+func TestDivisionByZero(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() (interface{}, error)
+	}{
+		{"div int", func() (interface{}, error) { return div(1, 0) }},
+		{"div float", func() (interface{}, error) { return div(1.5, 0.0) }},
+		{"mod int", func() (interface{}, error) { return mod(1, 0) }},
+		{"mod float", func() (interface{}, error) { return mod(1.5, 0.0) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.fn(); err != errDivisionByZero {
+				t.Errorf("got %v, %v, want errDivisionByZero", got, err)
+			}
+		})
+	}
+	runTemplateCases(t, `{"zero": 0}`, []templateCase{
+		{"in template", `{{ div 1 .zero }}`, "", true},
+	})
+}
+
+// round、percent 和 ratio 的舍入与退化情况，非数字参数为执行错误
+func TestMathInTemplates(t *testing.T) {
+	runTemplateCases(t, `{"n": 0}`, []templateCase{
+		{"add string", `{{ add "2" 3 }}`, "", true},
+		{"round", `{{ round 2.5 }}`, "3", false},
+		{"round places", `{{ round 3.14159 2 }}`, "3.14", false},
+		{"round negative", `{{ round -2.5 }}`, "-3", false},
+		{"percent", `{{ percent 1 8 }}`, "13%", false},
+		{"percent places", `{{ percent 1 8 1 }}`, "12.5%", false},
+		{"percent zero whole", `{{ percent 1 .n }}`, "0%", false},
+		{"percent fallback", `{{ percent 1 .n 0 "n/a" }}`, "n/a", false},
+		{"percent negative places", `{{ percent 1 8 -1 }}`, "", true},
+		{"ratio", `{{ ratio 1920 1080 }}`, "16:9", false},
+		{"ratio negative", `{{ ratio -4 6 }}`, "-2:3", false},
+		{"ratio zero", `{{ ratio 0 5 }}`, "0:1", false},
+		{"ratio both zero", `{{ ratio .n .n }}`, "0:0", false},
+		{"ratio fallback", `{{ ratio .n .n "-" }}`, "-", false},
+		{"ratio fraction", `{{ ratio 1.5 2 }}`, "", true},
+	})
+}
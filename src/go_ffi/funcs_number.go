@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ordinal 返回整数的英文序数形式："1st"、"2nd"、"3rd"、"4th"，以 11、12、13 结尾的数总是使用 "th"。
+// 负数同样适用（"-1st"），0 为 "0th"。
+func ordinal(v interface{}) (string, error) {
+	n, err := toInt(v)
+	if err != nil {
+		return "", err
+	}
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	suffix := "th"
+	if abs%100 < 11 || abs%100 > 13 {
+		switch abs % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix, nil
+}
+
+var (
+	englishOnes = []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+	}
+	englishTens   = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	englishScales = []string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+)
+
+// spellNumber 返回整数的英文读法（美式，不加 "and"）：42 为 "forty-two"，-1500 为 "minus one thousand five hundred"。
+// 目前只支持英文，其他语言可以按相同方式新增拼写函数。
+func spellNumber(v interface{}) (string, error) {
+	n, err := toInt(v)
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return englishOnes[0], nil
+	}
+
+	// 使用 uint64 计算绝对值，避免最小的负数取反后溢出
+	abs := uint64(n)
+	prefix := ""
+	if n < 0 {
+		abs = -abs
+		prefix = "minus "
+	}
+
+	var groups []string
+	for scale := 0; abs > 0; scale++ {
+		if group := abs % 1000; group > 0 {
+			words := spellEnglishHundreds(int(group))
+			if englishScales[scale] != "" {
+				words += " " + englishScales[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		abs /= 1000
+	}
+	return prefix + strings.Join(groups, " "), nil
+}
+
+// spellEnglishHundreds 返回 1 到 999 之间整数的英文读法
+func spellEnglishHundreds(n int) string {
+	var words []string
+	if n >= 100 {
+		words = append(words, englishOnes[n/100]+" hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, englishOnes[n])
+	case n%10 == 0:
+		words = append(words, englishTens[n/10])
+	default:
+		words = append(words, englishTens[n/10]+"-"+englishOnes[n%10])
+	}
+	return strings.Join(words, " ")
+}
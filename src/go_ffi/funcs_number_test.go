@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// 以 11、12、13 结尾的数（包括 111、1012）总是使用 th
+func TestOrdinal(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{0, "0th"},
+		{1, "1st"},
+		{2, "2nd"},
+		{3, "3rd"},
+		{4, "4th"},
+		{11, "11th"},
+		{12, "12th"},
+		{13, "13th"},
+		{14, "14th"},
+		{21, "21st"},
+		{22, "22nd"},
+		{23, "23rd"},
+		{101, "101st"},
+		{111, "111th"},
+		{112, "112th"},
+		{113, "113th"},
+		{1012, "1012th"},
+		{1021, "1021st"},
+		{-1, "-1st"},
+		{-12, "-12th"},
+		{-22, "-22nd"},
+		{3.0, "3rd"},
+	}
+	for _, tt := range tests {
+		got, err := ordinal(tt.in)
+		if err != nil {
+			t.Fatalf("ordinal(%v) failed: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("ordinal(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := ordinal(1.5); err == nil {
+		t.Error("ordinal(1.5) should fail")
+	}
+}
+
+func TestSpellNumber(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want string
+	}{
+		{0, "zero"},
+		{7, "seven"},
+		{13, "thirteen"},
+		{19, "nineteen"},
+		{20, "twenty"},
+		{42, "forty-two"},
+		{100, "one hundred"},
+		{115, "one hundred fifteen"},
+		{1000, "one thousand"},
+		{1001, "one thousand one"},
+		{-1500, "minus one thousand five hundred"},
+		{2000013, "two million thirteen"},
+		{int64(math.MinInt64), "minus nine quintillion two hundred twenty-three quadrillion three hundred seventy-two trillion thirty-six billion eight hundred fifty-four million seven hundred seventy-five thousand eight hundred eight"},
+	}
+	for _, tt := range tests {
+		got, err := spellNumber(tt.in)
+		if err != nil {
+			t.Fatalf("spellNumber(%v) failed: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("spellNumber(%v) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if _, err := spellNumber("many"); err == nil {
+		t.Error(`spellNumber("many") should fail`)
+	}
+}
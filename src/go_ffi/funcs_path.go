@@ -0,0 +1,34 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// urlSchemePattern 匹配开头的 URL scheme 及其后的 "//"，例如 "https://"
+	urlSchemePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+	repeatedSlashes  = regexp.MustCompile(`/{2,}`)
+)
+
+// pathJoin 用 "/" 连接各个路径段，与操作系统无关：空段和 nil 会被跳过，重复的分隔符合并为一个，
+// 例如 pathJoin "a/" "" "/b" 为 "a/b"。开头的 URL scheme（"https://"）保持不变，因此同样适用于 URL。
+// 与 pathClean 不同，"." 和 ".." 不会被解析，首段开头和末段结尾的 "/" 会被保留。
+func pathJoin(segments ...interface{}) string {
+	parts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if s := toString(segment); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	joined := strings.Join(parts, "/")
+
+	scheme := urlSchemePattern.FindString(joined)
+	return scheme + repeatedSlashes.ReplaceAllString(joined[len(scheme):], "/")
+}
+
+// pathClean 返回与 p 等价的最短路径，解析 "." 和 ".."，与 Go 的 path.Clean 相同，空路径返回 "."
+func pathClean(p string) string {
+	return path.Clean(p)
+}
@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+// pathJoin 跳过空段并合并重复的分隔符，只保留开头的 URL scheme，不解析 "." 和 ".."
+func TestPathJoin(t *testing.T) {
+	tests := []struct {
+		segments []interface{}
+		want     string
+	}{
+		{[]interface{}{"a", "b", "c"}, "a/b/c"},
+		{[]interface{}{"a/", "", "/b"}, "a/b"},
+		{[]interface{}{"a//b", "c"}, "a/b/c"},
+		{[]interface{}{"/a", "b/"}, "/a/b/"},
+		{[]interface{}{"a", nil, "b"}, "a/b"},
+		{[]interface{}{"v", 2, 1000000.0}, "v/2/1000000"},
+		{[]interface{}{"a", "..", "./b"}, "a/.././b"},
+		{[]interface{}{"https://example.com/", "/api", "v1"}, "https://example.com/api/v1"},
+		{[]interface{}{"file:///", "etc"}, "file:///etc"},
+		{[]interface{}{"s3://bucket//key"}, "s3://bucket/key"},
+		{[]interface{}{"a", "https://b"}, "a/https:/b"},
+		{[]interface{}{"/"}, "/"},
+		{[]interface{}{"", nil}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := pathJoin(tt.segments...); got != tt.want {
+			t.Errorf("pathJoin(%q) = %q, want %q", tt.segments, got, tt.want)
+		}
+	}
+}
+
+// pathClean 与 path.Clean 相同，与操作系统无关
+func TestPathClean(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"a/../b/./c", "b/c"},
+		{"a//b/", "a/b"},
+		{"/../a", "/a"},
+		{"../a/..", ".."},
+		{"a/..", "."},
+		{"", "."},
+		{"/", "/"},
+		{`a\b/../c`, "c"},
+	}
+	for _, tt := range tests {
+		if got := pathClean(tt.in); got != tt.want {
+			t.Errorf("pathClean(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPathInTemplates(t *testing.T) {
+	runTemplateCases(t, `{"base": "https://example.com/", "dir": "/srv/app/", "file": "config.yaml", "rel": "a/../b"}`, []templateCase{
+		{"url", `{{ pathJoin .base "v1" .missing "users" }}`, "https://example.com/v1/users", false},
+		{"file", `{{ pathJoin .dir "conf" .file }}`, "/srv/app/conf/config.yaml", false},
+		{"clean", `{{ pathClean .rel }}`, "b", false},
+		{"clean joined", `{{ pathJoin .dir "../etc" | pathClean }}`, "/srv/etc", false},
+		{"clean non-string", `{{ pathClean 1 }}`, "", true},
+	})
+}
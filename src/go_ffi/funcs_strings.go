@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// splitList 按 sep 切分字符串，语义与 Sprig 一致。
+// 切分空字符串会得到只包含一个空字符串的列表，而不是空列表。
+func splitList(sep string, s string) []string {
+	return strings.Split(s, sep)
+}
+
+// join 将列表中的元素转换为字符串后用 sep 连接，nil 元素会被跳过。
+// 传入的不是列表时按单个元素处理。
+func join(sep string, v interface{}) string {
+	return strings.Join(toStringSlice(v), sep)
+}
+
+// splitN 按 sep 最多切分为 n 段，n < 0 表示不限制，n == 0 返回空 map。
+// 与 Sprig 的 splitn 相同，结果是键为 _0、_1 … 的 map，便于用 (splitN "=" 2 .pair)._1 取出某一段；
+// 与 splitList 相同，切分空字符串（且 n != 0）会得到 {"_0": ""}。
+func splitN(sep string, n interface{}, s string) (map[string]string, error) {
+	count, err := toInt(n)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(s, sep, count)
+	out := make(map[string]string, len(parts))
+	for i, part := range parts {
+		out[fmt.Sprintf("_%d", i)] = part
+	}
+	return out, nil
+}
+
+// trimAll 去掉字符串首尾所有出现在 cutset 中的字符。
+func trimAll(cutset string, s string) string {
+	return strings.Trim(s, cutset)
+}
+
+// splitWords 将标识符拆分为单词，供命名风格转换使用。拆分规则：
+//   - 非字母数字字符（如 _、-、空格、.）视为分隔符；
+//   - 小写字母或数字后紧跟大写字母时断开（fooBar -> foo Bar）；
+//   - 连续大写字母视为缩写，在最后一个大写字母前断开（HTTPServer -> HTTP Server）；
+//   - 数字归属于前一个单词（utf8Decoder -> utf8 Decoder），数字后的小写字母不断开。
+func splitWords(s string) []string {
+	var words []string
+	var current []rune
+
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// camelcase 转换为首字母大写的驼峰形式（与 Sprig 一致）：http_server -> HttpServer。
+// 缩写不会被保留，HTTPServer 同样得到 HttpServer。
+func camelcase(s string) string {
+	var b strings.Builder
+	for _, w := range splitWords(s) {
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// snakecase 转换为小写下划线形式：FirstName -> first_name, HTTPServer -> http_server。
+func snakecase(s string) string {
+	return joinLowerWords(s, "_")
+}
+
+// kebabcase 转换为小写连字符形式：FirstName -> first-name。
+func kebabcase(s string) string {
+	return joinLowerWords(s, "-")
+}
+
+func joinLowerWords(s string, sep string) string {
+	words := splitWords(s)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, sep)
+}
+
+// slugify 生成 GitHub 风格的锚点 ID：转为小写，空白、- 和 _ 替换为 -，
+// 其余标点符号直接去掉，连续的 - 合并为一个，并去掉首尾的 -。
+// 非 ASCII 的字母和数字会转为小写后保留（与 GitHub 一致），不做音译。
+func slugify(s string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if pendingHyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingHyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			pendingHyphen = true
+		}
+	}
+	return b.String()
+}
+
+// defaultEllipsis 是 truncate 和 abbrev 默认追加的省略号
+const defaultEllipsis = "…"
+
+// ellipsisArgs 拆分 truncate 和 abbrev 的可变参数：只有一个参数时为字符串本身，
+// 两个参数时依次为省略号和字符串，这样字符串始终是最后一个参数，便于在管道中使用
+func ellipsisArgs(n interface{}, args []string) (int, string, string, error) {
+	limit, err := toInt(n)
+	if err != nil {
+		return 0, "", "", err
+	}
+	if limit < 0 {
+		return 0, "", "", fmt.Errorf("length must not be negative, got %d", limit)
+	}
+	switch len(args) {
+	case 1:
+		return limit, defaultEllipsis, args[0], nil
+	case 2:
+		return limit, args[0], args[1], nil
+	default:
+		return 0, "", "", fmt.Errorf("expected [ellipsis] string after the length, got %d arguments", len(args))
+	}
+}
+
+// truncate 将字符串截断为 n 个字符（按 rune 计，不会截断多字节字符）并追加省略号，
+// 省略号不计入长度；不超过 n 个字符的字符串原样返回。
+// 用法为 truncate n s 或 truncate n ellipsis s。
+func truncate(n interface{}, args ...string) (string, error) {
+	limit, ellipsis, s, err := ellipsisArgs(n, args)
+	if err != nil {
+		return "", err
+	}
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s, nil
+	}
+	return string(runes[:limit]) + ellipsis, nil
+}
+
+// abbrev 与 truncate 类似，但省略号计入长度，结果总长度不超过 n 个字符。
+// n 不足以容纳省略号时直接截断为 n 个字符，不追加省略号。
+func abbrev(n interface{}, args ...string) (string, error) {
+	limit, ellipsis, s, err := ellipsisArgs(n, args)
+	if err != nil {
+		return "", err
+	}
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s, nil
+	}
+	width := utf8.RuneCountInString(ellipsis)
+	if limit <= width {
+		return string(runes[:limit]), nil
+	}
+	return string(runes[:limit-width]) + ellipsis, nil
+}
+
+// indent 在每一行（包括第一行）前加上 spaces 个空格，语义与 Sprig 一致
+func indent(spaces interface{}, v interface{}) (interface{}, error) {
+	return indentLines(spaces, v, "")
+}
+
+// nindent 与 indent 相同，但会先在开头加一个换行符
+func nindent(spaces interface{}, v interface{}) (interface{}, error) {
+	return indentLines(spaces, v, "\n")
+}
+
+// indentLines 为每一行加上缩进并在最前面加上 prefix。
+// include 在 HTML 模式下返回的 template.HTML 会保持该类型，避免被再次转义。
+func indentLines(spaces interface{}, v interface{}, prefix string) (interface{}, error) {
+	n, err := toInt(spaces)
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("indent must not be negative, got %d", n)
+	}
+	pad := strings.Repeat(" ", n)
+	indented := func(s string) string {
+		return prefix + pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	}
+	if h, ok := v.(htmltemplate.HTML); ok {
+		return htmltemplate.HTML(indented(string(h))), nil
+	}
+	return indented(toString(v)), nil
+}
+
+const (
+	// defaultMaskReveal 是 mask 默认保留的末尾字符数
+	defaultMaskReveal = 4
+	// redactedToken 是 redact 的输出
+	redactedToken = "[REDACTED]"
+)
+
+// mask 将字符串除最后 n 个字符（默认 4 个）以外的字符替换为 "*"，按 rune 计，不会破坏多字节字符。
+// 保留的字符数不超过字符串长度的一半，避免短值几乎全部暴露：默认值会被截到一半，
+// 显式给出的 n 超过一半时返回错误；nil 返回空字符串。
+// 用法为 mask s 或 mask n s，便于在管道中使用：{{ .apiKey | mask }}
+func mask(args ...interface{}) (string, error) {
+	reveal := defaultMaskReveal
+	explicit := len(args) == 2
+	switch len(args) {
+	case 1:
+	case 2:
+		n, err := toInt(args[0])
+		if err != nil {
+			return "", err
+		}
+		if n < 0 {
+			return "", fmt.Errorf("reveal count must not be negative, got %d", n)
+		}
+		reveal = n
+	default:
+		return "", fmt.Errorf("expected [reveal count] and a value, got %d arguments", len(args))
+	}
+
+	runes := []rune(toString(args[len(args)-1]))
+	if reveal > len(runes)/2 {
+		if explicit {
+			return "", fmt.Errorf("reveal count %d exceeds half of the %d-character value", reveal, len(runes))
+		}
+		reveal = len(runes) / 2
+	}
+	hidden := len(runes) - reveal
+	return strings.Repeat("*", hidden) + string(runes[hidden:]), nil
+}
+
+// redact 忽略参数的内容，总是返回 "[REDACTED]"，用于完全隐藏敏感值：{{ .password | redact }}
+func redact(v interface{}) string {
+	return redactedToken
+}
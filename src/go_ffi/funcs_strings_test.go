@@ -0,0 +1,154 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// splitList 与 strings.Split 相同，切分空字符串得到一个空字符串元素
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		sep  string
+		s    string
+		want []string
+	}{
+		{",", "a,b,c", []string{"a", "b", "c"}},
+		{",", "a,,b", []string{"a", "", "b"}},
+		{",", "abc", []string{"abc"}},
+		{",", "", []string{""}},
+		{",", ",", []string{"", ""}},
+		{"", "ab", []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		if got := splitList(tt.sep, tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitList(%q, %q) = %q, want %q", tt.sep, tt.s, got, tt.want)
+		}
+	}
+}
+
+// splitN 与 Sprig 的 splitn 相同，返回键为 _0、_1 … 的 map
+func TestSplitN(t *testing.T) {
+	tests := []struct {
+		n    interface{}
+		s    string
+		want map[string]string
+	}{
+		{2, "a=b=c", map[string]string{"_0": "a", "_1": "b=c"}},
+		{-1, "a=b=c", map[string]string{"_0": "a", "_1": "b", "_2": "c"}},
+		{5, "a=b", map[string]string{"_0": "a", "_1": "b"}},
+		{0, "a=b", map[string]string{}},
+		{2, "", map[string]string{"_0": ""}},
+		{"2", "a=b=c", map[string]string{"_0": "a", "_1": "b=c"}},
+	}
+	for _, tt := range tests {
+		got, err := splitN("=", tt.n, tt.s)
+		if err != nil {
+			t.Fatalf("splitN(%v, %q) failed: %v", tt.n, tt.s, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitN(%v, %q) = %q, want %q", tt.n, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSplitJoinInTemplates(t *testing.T) {
+	runTemplateCases(t, `{"tags": "a,b", "pair": "key=va=lue", "empty": "", "nums": [1000000, 1.5, null, true]}`, []templateCase{
+		{"range splitList", `{{ range splitList "," .tags }}[{{ . }}]{{ end }}`, "[a][b]", false},
+		{"splitList empty", `{{ len (splitList "," .empty) }}`, "1", false},
+		{"splitN field", `{{ (splitN "=" 2 .pair)._1 }}`, "va=lue", false},
+		{"splitN non-integer", `{{ splitN "=" "x" .pair }}`, "", true},
+		{"join", `{{ join "-" (splitList "," .tags) }}`, "a-b", false},
+		{"join numbers", `{{ join "," .nums }}`, "1000000,1.5,true", false},
+		{"join scalar", `{{ join "," 2.5 }}`, "2.5", false},
+		{"trimAll", `{{ trimAll "-_" "-_x_-" }}`, "x", false},
+	})
+}
+
+// 命名风格转换按 splitWords 拆分单词：缩写作为一个单词，在最后一个大写字母前断开，数字归属于前一个单词
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		in    string
+		camel string
+		snake string
+		kebab string
+	}{
+		{"first_name", "FirstName", "first_name", "first-name"},
+		{"FirstName", "FirstName", "first_name", "first-name"},
+		{"firstName", "FirstName", "first_name", "first-name"},
+		{"HTTPServer", "HttpServer", "http_server", "http-server"},
+		{"XMLHttpRequest", "XmlHttpRequest", "xml_http_request", "xml-http-request"},
+		{"getHTTPResponseCode", "GetHttpResponseCode", "get_http_response_code", "get-http-response-code"},
+		{"userID", "UserId", "user_id", "user-id"},
+		{"ABC", "Abc", "abc", "abc"},
+		{"utf8Decoder", "Utf8Decoder", "utf8_decoder", "utf8-decoder"},
+		{"version2beta", "Version2beta", "version2beta", "version2beta"},
+		{"  foo--bar.baz ", "FooBarBaz", "foo_bar_baz", "foo-bar-baz"},
+		{"ÄpfelBaum", "ÄpfelBaum", "äpfel_baum", "äpfel-baum"},
+		{"x", "X", "x", "x"},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		if got := camelcase(tt.in); got != tt.camel {
+			t.Errorf("camelcase(%q) = %q, want %q", tt.in, got, tt.camel)
+		}
+		if got := snakecase(tt.in); got != tt.snake {
+			t.Errorf("snakecase(%q) = %q, want %q", tt.in, got, tt.snake)
+		}
+		if got := kebabcase(tt.in); got != tt.kebab {
+			t.Errorf("kebabcase(%q) = %q, want %q", tt.in, got, tt.kebab)
+		}
+	}
+}
+
+// mask 按 rune 保留末尾字符，默认值截到一半，显式的保留数超过一半时报错；redact 总是返回固定标记
+func TestMaskRedact(t *testing.T) {
+	runTemplateCases(t, `{"key": "abcdefghijklmnop", "short": "abc", "wide": "密码是六个字", "n": 123456, "none": null}`, []templateCase{
+		{"default", `{{ .key | mask }}`, "************mnop", false},
+		{"explicit", `{{ mask 2 .key }}`, "**************op", false},
+		{"explicit half", `{{ mask 8 .key }}`, "********ijklmnop", false},
+		{"explicit zero", `{{ mask 0 .short }}`, "***", false},
+		{"string count", `{{ mask "3" .key }}`, "*************nop", false},
+		{"default capped", `{{ mask .short }}`, "**c", false},
+		{"runes", `{{ mask .wide }}`, "***六个字", false},
+		{"number", `{{ mask .n }}`, "***456", false},
+		{"nil", `{{ mask .none }}`, "", false},
+		{"empty", `{{ mask "" }}`, "", false},
+		{"explicit over half", `{{ mask 9 .key }}`, "", true},
+		{"explicit over short", `{{ mask 2 .short }}`, "", true},
+		{"negative", `{{ mask -1 .key }}`, "", true},
+		{"non-integer", `{{ mask "x" .key }}`, "", true},
+		{"no arguments", `{{ mask }}`, "", true},
+		{"too many arguments", `{{ mask 1 2 .key }}`, "", true},
+		{"redact", `{{ .key | redact }}`, "[REDACTED]", false},
+		{"redact nil", `{{ redact .none }}`, "[REDACTED]", false},
+		{"redact missing", `{{ redact .missing }}`, "[REDACTED]", false},
+	})
+}
+
+// truncate 的省略号不计入长度，abbrev 的省略号计入长度；两者都按 rune 计数
+func TestTruncateAbbrev(t *testing.T) {
+	runTemplateCases(t, `{"s": "hello world", "wide": "你好世界", "n": 5}`, []templateCase{
+		{"truncate", `{{ truncate 5 .s }}`, "hello…", false},
+		{"truncate pipeline", `{{ .s | truncate 5 }}`, "hello…", false},
+		{"truncate custom ellipsis", `{{ truncate 5 "..." .s }}`, "hello...", false},
+		{"truncate empty ellipsis", `{{ truncate 5 "" .s }}`, "hello", false},
+		{"truncate exact length", `{{ truncate 11 .s }}`, "hello world", false},
+		{"truncate short", `{{ truncate 20 .s }}`, "hello world", false},
+		{"truncate zero", `{{ truncate 0 .s }}`, "…", false},
+		{"truncate runes", `{{ truncate 2 .wide }}`, "你好…", false},
+		{"truncate string length", `{{ truncate "3" .s }}`, "hel…", false},
+		{"truncate data length", `{{ truncate .n .s }}`, "hello…", false},
+		{"abbrev", `{{ abbrev 5 .s }}`, "hell…", false},
+		{"abbrev custom ellipsis", `{{ abbrev 8 "..." .s }}`, "hello...", false},
+		{"abbrev exact length", `{{ abbrev 11 .s }}`, "hello world", false},
+		{"abbrev runes", `{{ abbrev 3 .wide }}`, "你好…", false},
+		{"abbrev ellipsis too long", `{{ abbrev 2 "..." .s }}`, "he", false},
+		{"abbrev ellipsis fills length", `{{ abbrev 3 "..." .s }}`, "hel", false},
+		{"abbrev zero", `[{{ abbrev 0 .s }}]`, "[]", false},
+		{"negative length", `{{ truncate -1 .s }}`, "", true},
+		{"abbrev negative length", `{{ abbrev -1 .s }}`, "", true},
+		{"non-integer length", `{{ truncate "x" .s }}`, "", true},
+		{"missing string", `{{ truncate 5 }}`, "", true},
+		{"too many arguments", `{{ truncate 5 "." "," .s }}`, "", true},
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// table 将 rows（由 map 组成的列表）按 columns 的顺序格式化为对齐的表格。
+// format 为 ascii 或 markdown；columns 可以是列表，也可以是逗号分隔的字符串。
+// 列宽按字符（rune）计算，缺失的字段输出为空单元格。结果末尾不带换行符。
+func table(format string, columns interface{}, rows interface{}) (string, error) {
+	if format != "ascii" && format != "markdown" {
+		return "", fmt.Errorf("unsupported table format %q (expected ascii or markdown)", format)
+	}
+	cols := tableColumns(columns)
+	if len(cols) == 0 {
+		return "", fmt.Errorf("at least one column is required")
+	}
+	items, err := toList(rows)
+	if err != nil {
+		return "", err
+	}
+
+	cells := make([][]string, len(items))
+	for i, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok && item != nil {
+			return "", fmt.Errorf("row %d must be a map, got %T", i, item)
+		}
+		cells[i] = make([]string, len(cols))
+		for j, col := range cols {
+			cells[i][j] = tableCell(row[col], format)
+		}
+	}
+
+	widths := make([]int, len(cols))
+	for j, col := range cols {
+		widths[j] = utf8.RuneCountInString(tableCell(col, format))
+		if format == "markdown" && widths[j] < 3 {
+			// Markdown 的分隔行至少需要三个 -
+			widths[j] = 3
+		}
+		for _, row := range cells {
+			if w := utf8.RuneCountInString(row[j]); w > widths[j] {
+				widths[j] = w
+			}
+		}
+	}
+
+	header := make([]string, len(cols))
+	for j, col := range cols {
+		header[j] = tableCell(col, format)
+	}
+	var lines []string
+	if format == "ascii" {
+		border := tableBorder(widths)
+		lines = append(lines, border, tableRow(header, widths), border)
+		for _, row := range cells {
+			lines = append(lines, tableRow(row, widths))
+		}
+		lines = append(lines, border)
+	} else {
+		separator := make([]string, len(cols))
+		for j, w := range widths {
+			separator[j] = strings.Repeat("-", w)
+		}
+		lines = append(lines, tableRow(header, widths), tableRow(separator, widths))
+		for _, row := range cells {
+			lines = append(lines, tableRow(row, widths))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// tableColumns 将列名参数转换为字符串列表，字符串按逗号切分并去掉空白
+func tableColumns(columns interface{}) []string {
+	s, ok := columns.(string)
+	if !ok {
+		return toStringSlice(columns)
+	}
+	var cols []string
+	for _, col := range strings.Split(s, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			cols = append(cols, col)
+		}
+	}
+	return cols
+}
+
+// tableCell 将单元格的值转换为单行文本，Markdown 中的 | 会被转义
+func tableCell(v interface{}, format string) string {
+	s := strings.Join(strings.Fields(toString(v)), " ")
+	if format == "markdown" {
+		s = strings.ReplaceAll(s, "|", `\|`)
+	}
+	return s
+}
+
+// tableRow 输出一行单元格，每个单元格按列宽左对齐
+func tableRow(cells []string, widths []int) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for j, cell := range cells {
+		b.WriteString(" ")
+		b.WriteString(cell)
+		b.WriteString(strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)))
+		b.WriteString(" |")
+	}
+	return b.String()
+}
+
+// tableBorder 输出 ASCII 表格的边框行，例如 +------+-----+
+func tableBorder(widths []int) string {
+	var b strings.Builder
+	b.WriteString("+")
+	for _, w := range widths {
+		b.WriteString(strings.Repeat("-", w+2))
+		b.WriteString("+")
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// 列宽按字符（rune）而不是字节计算，因此多字节字符的单元格与 ASCII 单元格按字符数对齐。
+// 宽度不考虑终端中的显示宽度，名字 占两个字符位置，在等宽字体中显示会更宽
+func TestTable(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"name": "café", "n": 1.0},
+		map[string]interface{}{"name": "名字", "n": 22.0, "note": "a|b"},
+		map[string]interface{}{"name": "x"},
+	}
+	tests := []struct {
+		name    string
+		format  string
+		columns interface{}
+		want    []string
+	}{
+		{
+			"ascii",
+			"ascii",
+			"name, n",
+			[]string{
+				"+------+----+",
+				"| name | n  |",
+				"+------+----+",
+				"| café | 1  |",
+				"| 名字   | 22 |",
+				"| x    |    |",
+				"+------+----+",
+			},
+		},
+		{
+			"markdown",
+			"markdown",
+			[]interface{}{"name", "note"},
+			[]string{
+				"| name | note |",
+				"| ---- | ---- |",
+				"| café |      |",
+				"| 名字   | a\\|b |",
+				"| x    |      |",
+			},
+		},
+		{
+			"markdown minimum width",
+			"markdown",
+			"n",
+			[]string{
+				"| n   |",
+				"| --- |",
+				"| 1   |",
+				"| 22  |",
+				"|     |",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := table(tt.format, tt.columns, rows)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("got\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// 多行内容合并为一行，nil 行输出为空单元格
+func TestTableCells(t *testing.T) {
+	got, err := table("ascii", "a", []interface{}{map[string]interface{}{"a": "one\n  two"}, nil})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := strings.Join([]string{
+		"+---------+",
+		"| a       |",
+		"+---------+",
+		"| one two |",
+		"|         |",
+		"+---------+",
+	}, "\n")
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTableErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		columns interface{}
+		rows    interface{}
+		want    string
+	}{
+		{"format", "html", "a", nil, `unsupported table format "html" (expected ascii or markdown)`},
+		{"no columns", "ascii", " , ", nil, "at least one column is required"},
+		{"row type", "ascii", "a", []interface{}{"x"}, "row 0 must be a map, got string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := table(tt.format, tt.columns, tt.rows)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
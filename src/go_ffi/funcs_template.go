@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"sync/atomic"
+)
+
+// maxIncludeDepth 限制 include 类函数的嵌套深度。
+// Go 的栈溢出无法恢复，会直接终止宿主进程，因此必须在递归失控前报错。
+const maxIncludeDepth = 100
+
+// templateSet 保存一次渲染中解析得到的模板集合，
+// 为需要访问模板集合本身的辅助函数提供闭包状态，每次渲染都会新建一个。
+type templateSet struct {
+	tmpl       goTemplate
+	escapeHtml bool
+	depth      int
+	// opts 和 funcMap 为解析根模板时使用的选项和函数，renderString 以相同的方式解析模板字符串
+	opts    renderOptions
+	funcMap map[string]interface{}
+	// resetCounters 清空 counterFuncs 的计数器，由 reset 调用
+	resetCounters func()
+	// source 为解析时的模板源码，renderOnce 在执行超时后据此重新解析
+	source string
+	// running 为 renderOnce 开始后尚未结束的执行次数，超时后被放弃的执行结束前不会减少
+	running int32
+}
+
+// reset 清空上一次执行留下的渲染内状态，同一份解析结果每次执行前调用，
+// 使 next 等函数在每次渲染中都从头开始
+func (s *templateSet) reset() {
+	s.depth = 0
+	if s.resetCounters != nil {
+		s.resetCounters()
+	}
+}
+
+// renderOnce 在同一份解析结果上执行一次渲染，name 为空时执行根模板，执行前先调用 reset。
+// RenderAll、RenderMatrix 和 RenderBatch 对每个单元调用它，并在之后的执行中使用返回的集合：
+// 执行超时后被放弃的 goroutine 仍在使用计数器和 include 深度，继续复用会与它并发读写，
+// 而并发写 map 会直接终止宿主进程，因此这时返回重新解析的集合。
+func (s *templateSet) renderOnce(kind string, name string, data interface{}) (RenderResult, *templateSet) {
+	s.reset()
+	atomic.AddInt32(&s.running, 1)
+	result := renderParsed(trackedTemplate{s}, kind, name, data, s.opts)
+	if atomic.LoadInt32(&s.running) == 0 {
+		return result, s
+	}
+	fresh, _, err := parseTemplateSet(s.source, s.opts)
+	if err != nil {
+		// 同样的源码和选项已经解析成功过一次，这里不会失败
+		return result, s
+	}
+	return result, fresh
+}
+
+// trackedTemplate 在每次执行结束时减少 set.running，供 renderOnce 判断执行是否已经结束
+type trackedTemplate struct {
+	set *templateSet
+}
+
+func (t trackedTemplate) Execute(w io.Writer, data interface{}) error {
+	defer atomic.AddInt32(&t.set.running, -1)
+	return t.set.tmpl.Execute(w, data)
+}
+
+func (t trackedTemplate) ExecuteTemplate(w io.Writer, name string, data interface{}) error {
+	defer atomic.AddInt32(&t.set.running, -1)
+	return t.set.tmpl.ExecuteTemplate(w, name, data)
+}
+
+// funcs 返回依赖模板集合的辅助函数，需要在解析之前注册。
+// renderString 会执行来自数据的模板文本，只有 opts.AllowRenderString 为 true 时才注册。
+func (s *templateSet) funcs(opts renderOptions) map[string]interface{} {
+	funcs := map[string]interface{}{
+		"include":        s.include,
+		"includeOrEmpty": s.includeOrEmpty,
+	}
+	if opts.AllowRenderString {
+		funcs["renderString"] = s.renderString
+	}
+	return funcs
+}
+
+// include 执行指定名称的模板并返回其输出，与 Helm 的 include 相同。
+// 与 template 动作直接写入输出不同，返回值可以继续在管道中处理，例如 include "x" . | indent 4。
+func (s *templateSet) include(name string, data interface{}) (interface{}, error) {
+	if !hasTemplate(s.tmpl, name) {
+		return nil, fmt.Errorf("template %q is not defined", name)
+	}
+	return s.execute(name, data)
+}
+
+// includeOrEmpty 执行指定名称的模板并返回其输出，模板未定义时返回空字符串而不是报错
+func (s *templateSet) includeOrEmpty(name string, data interface{}) (interface{}, error) {
+	if !hasTemplate(s.tmpl, name) {
+		return "", nil
+	}
+	return s.execute(name, data)
+}
+
+// renderStringError 是 renderString 返回的错误。嵌套的 renderString 失败时只保留最内层的原因，
+// 避免每一层都重复一遍 "executing ... error calling renderString: " 前缀。
+type renderStringError struct {
+	err error
+}
+
+func (e renderStringError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 使 errors.As 仍然可以识别其中的 templateFailure
+func (e renderStringError) Unwrap() error {
+	return e.err
+}
+
+// renderString 将字符串 src 作为模板解析，并以 data 执行，返回其输出，用于执行存放在数据中的模板。
+// 解析时使用与根模板相同的分隔符、转义方式和函数，因此 src 中同样可以调用 include 和 renderString，
+// 嵌套深度与 include 共用 maxIncludeDepth 限制。src 中的 define 只在本次调用中可见。
+func (s *templateSet) renderString(src string, data interface{}) (interface{}, error) {
+	if s.depth >= maxIncludeDepth {
+		return nil, renderStringError{fmt.Errorf("exceeded the maximum include depth of %d", maxIncludeDepth)}
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+
+	tmpl, err := parseSource("renderString", src, s.opts, s.funcMap)
+	if err != nil {
+		return nil, renderStringError{err}
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		var inner renderStringError
+		if errors.As(err, &inner) {
+			return nil, inner
+		}
+		return nil, renderStringError{err}
+	}
+	if s.escapeHtml {
+		return htmltemplate.HTML(buf.String()), nil
+	}
+	return buf.String(), nil
+}
+
+// execute 将指定模板渲染为字符串。
+// 在 html/template 中结果已经按上下文转义过，因此以 template.HTML 返回，避免被再次转义。
+func (s *templateSet) execute(name string, data interface{}) (interface{}, error) {
+	if s.depth >= maxIncludeDepth {
+		return nil, fmt.Errorf("template %q exceeded the maximum include depth of %d", name, maxIncludeDepth)
+	}
+	s.depth++
+	defer func() { s.depth-- }()
+
+	var buf bytes.Buffer
+	if err := s.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, err
+	}
+	if s.escapeHtml {
+		return htmltemplate.HTML(buf.String()), nil
+	}
+	return buf.String(), nil
+}
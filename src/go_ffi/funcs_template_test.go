@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// renderString 默认不注册，只有设置 allowRenderString 后才能执行来自数据的模板
+func TestRenderStringRequiresOptIn(t *testing.T) {
+	data := `{"intro": "Hello {{ .name }}", "name": "Ada"}`
+
+	result := renderGoTemplate(`{{ renderString .intro . }}`, data, renderOptions{})
+	if !strings.Contains(result.Error, `function "renderString" not defined`) {
+		t.Errorf("expected renderString to be undefined by default, got output %q, error %q", result.Output, result.Error)
+	}
+
+	for _, escapeHtml := range []bool{false, true} {
+		result = renderGoTemplate(`{{ renderString .intro . }}`, data, renderOptions{AllowRenderString: true, EscapeHtml: escapeHtml})
+		if result.Error != "" || result.Output != "Hello Ada" {
+			t.Errorf("escapeHtml=%v: got output %q, error %q", escapeHtml, result.Output, result.Error)
+		}
+	}
+}
+
+// 嵌套的 renderString 失败时，错误只包含最外层的位置和最内层的原因
+func TestRenderStringErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			"nested execution error",
+			`{"a": "{{ renderString .b . }}", "b": "{{ renderString .c . }}", "c": "{{ index 1 2 }}"}`,
+			`executing "goTemplate" at <renderString .a .>: error calling renderString: template: renderString:1:3: executing "renderString" at <index 1 2>: error calling index: can't index item of type int`,
+		},
+		{
+			"parse error",
+			`{"a": "{{ renderString .b . }}", "b": "{{"}`,
+			`error calling renderString: template: renderString:1: unclosed action`,
+		},
+		{
+			"unbounded recursion",
+			`{"a": "{{ renderString .a . }}"}`,
+			`error calling renderString: exceeded the maximum include depth of 100`,
+		},
+		{
+			"template failure",
+			`{"a": "{{ renderString .b . }}", "b": "{{ fail \"boom\" }}"}`,
+			templateFailurePrefix + "boom",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(`{{ renderString .a . }}`, tt.data, renderOptions{AllowRenderString: true})
+			if !strings.HasSuffix(result.Error, tt.want) {
+				t.Fatalf("got error %q, want suffix %q", result.Error, tt.want)
+			}
+			if n := strings.Count(result.Error, "error calling renderString"); n > 1 {
+				t.Errorf("error repeats the renderString prefix %d times: %q", n, result.Error)
+			}
+		})
+	}
+}
+
+// templateLine 在解析时被替换为调用所在的行号，define 块和 renderString 中的行号相对于各自的源码
+func TestTemplateLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     renderOptions
+		want     string
+		wantErr  bool
+	}{
+		{"first line", `{{ templateLine }}`, renderOptions{}, "1", false},
+		{"later line", "a\nb\n{{ templateLine }}", renderOptions{}, "a\nb\n3", false},
+		{"same line", `{{ templateLine }}{{ templateLine }}`, renderOptions{}, "11", false},
+		{"after multi-line comment", "{{/* a\nb */}}{{ templateLine }}", renderOptions{}, "2", false},
+		{"argument", "\n{{ printf \"line %d\" templateLine }}", renderOptions{}, "\nline 2", false},
+		{"pipeline", "\n\n{{ templateLine | printf \"L%d\" }}", renderOptions{}, "\n\nL3", false},
+		{"variable", "{{ $l := templateLine }}\n{{ $l }}", renderOptions{}, "\n1", false},
+		{"multi-line action", "{{ printf \"%d-%d\"\n  templateLine\n  templateLine }}", renderOptions{}, "2-3", false},
+		{"inside define", "{{ define \"x\" }}\n{{ templateLine }}{{ end }}{{ template \"x\" }}", renderOptions{}, "\n2", false},
+		{"include", "{{ define \"x\" }}{{ templateLine }}{{ end }}\n\n{{ include \"x\" . }}", renderOptions{}, "\n\n1", false},
+		{"custom delimiters", "x\n[[ templateLine ]] {{ templateLine }}", renderOptions{LeftDelim: "[[", RightDelim: "]]"}, "x\n2 {{ templateLine }}", false},
+		{"escapeHtml", "\n{{ templateLine }}", renderOptions{EscapeHtml: true}, "\n2", false},
+		{"renderString", "\n\n{{ renderString \"\\n{{ templateLine }}\" . }}", renderOptions{AllowRenderString: true}, "\n\n\n2", false},
+		{"with arguments", `{{ templateLine 1 }}`, renderOptions{}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(tt.template, `{}`, tt.opts)
+			if tt.wantErr {
+				if result.Error == "" {
+					t.Errorf("expected an error, got output %q", result.Output)
+				}
+				return
+			}
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// include 返回模板的输出以便继续在管道中处理；indent 缩进每一行，nindent 另外在开头加一个换行符
+func TestIncludeIndent(t *testing.T) {
+	const defs = `{{ define "pair" }}a: {{ .a }}
+b: {{ .b }}{{ end }}{{ define "tag" }}<b>{{ . }}</b>{{ end }}`
+	tests := []struct {
+		name       string
+		template   string
+		escapeHtml bool
+		want       string
+		wantErr    bool
+	}{
+		{"include", `{{ include "pair" . }}`, false, "a: 1\nb: <x>", false},
+		{"include other data", `{{ include "tag" .a }}`, false, "<b>1</b>", false},
+		{"indent", `{{ include "pair" . | indent 2 }}`, false, "  a: 1\n  b: <x>", false},
+		{"nindent", `list:{{ include "pair" . | nindent 4 }}`, false, "list:\n    a: 1\n    b: <x>", false},
+		{"indent zero", `{{ indent 0 "a\nb" }}`, false, "a\nb", false},
+		{"indent string count", `{{ indent "1" "a\nb" }}`, false, " a\n b", false},
+		{"indent empty lines", `{{ indent 2 "a\n\nb\n" }}`, false, "  a\n  \n  b\n  ", false},
+		{"indent number", `{{ indent 2 .a }}`, false, "  1", false},
+		{"nindent empty", `[{{ nindent 2 "" }}]`, false, "[\n  ]", false},
+		{"include html", `{{ include "tag" .b }}`, true, "<b>&lt;x&gt;</b>", false},
+		{"indent html keeps escaping once", `{{ include "pair" . | indent 2 }}`, true, "  a: 1\n  b: &lt;x&gt;", false},
+		{"indent html string", `{{ indent 1 .b }}`, true, " &lt;x&gt;", false},
+		{"undefined template", `{{ include "missing" . }}`, false, "", true},
+		{"negative indent", `{{ indent -1 "a" }}`, false, "", true},
+		{"nindent negative", `{{ nindent -2 "a" }}`, false, "", true},
+		{"non-integer indent", `{{ indent "x" "a" }}`, false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(defs+tt.template, `{"a": 1, "b": "<x>"}`, renderOptions{EscapeHtml: tt.escapeHtml})
+			if tt.wantErr {
+				if result.Error == "" {
+					t.Errorf("expected an error, got output %q", result.Output)
+				}
+				return
+			}
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// include 的错误信息给出未定义的模板名，递归超过 maxIncludeDepth 时报错而不是无限递归
+func TestIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"undefined", `{{ include "missing" . }}`, `template "missing" is not defined`},
+		{"recursion", `{{ define "loop" }}{{ include "loop" . }}{{ end }}{{ include "loop" . }}`, `template "loop" exceeded the maximum include depth of 100`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderGoTemplate(tt.template, `{}`, renderOptions{})
+			if !strings.HasSuffix(result.Error, tt.want) {
+				t.Errorf("got error %q, want suffix %q", result.Error, tt.want)
+			}
+		})
+	}
+}
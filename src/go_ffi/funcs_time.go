@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeFuncs 返回依赖当前时间的辅助函数。
+// now 由渲染选项决定，传入固定的参考时间可以让输出不随运行时间变化，便于测试。
+func timeFuncs(now func() time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"timeAgo": func(ts string) (string, error) {
+			return timeAgo(ts, now())
+		},
+	}
+}
+
+// relativeUnits 按从大到小的顺序列出 timeAgo 使用的时间单位，月和年按 30 天和 365 天近似
+var relativeUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+	{"second", time.Second},
+}
+
+// timeAgo 将 RFC3339 时间戳格式化为相对于 now 的描述，例如 "3 hours ago" 或 "in 2 days"。
+// 只取最大的单位并向下取整，不足一秒时返回 "just now"。
+func timeAgo(ts string, now time.Time) (string, error) {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return "", fmt.Errorf("invalid RFC3339 timestamp %q", ts)
+	}
+
+	diff := now.Sub(t)
+	future := diff < 0
+	if future {
+		diff = -diff
+	}
+
+	for _, unit := range relativeUnits {
+		if diff < unit.size {
+			continue
+		}
+		n := int64(diff / unit.size)
+		label := fmt.Sprintf("%d %s", n, unit.name)
+		if n != 1 {
+			label += "s"
+		}
+		if future {
+			return "in " + label, nil
+		}
+		return label + " ago", nil
+	}
+	return "just now", nil
+}
+
+// toDuration 将 Go 时长语法的字符串（"1h30m"、"90s"、"250ms"）或 time.Duration 转换为 time.Duration
+func toDuration(v interface{}) (time.Duration, error) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, nil
+	case string:
+		parsed, err := time.ParseDuration(d)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", d)
+		}
+		return parsed, nil
+	}
+	return 0, fmt.Errorf("expected a duration string, got %T", v)
+}
+
+// parseDuration 解析时长字符串。返回的 time.Duration 可以直接用 lt、gt、eq 比较，
+// 输出时与 Go 的 String 相同（"1h30m0s"）
+func parseDuration(v interface{}) (time.Duration, error) {
+	return toDuration(v)
+}
+
+// formatDuration 按 layout 格式化时长 v，参数顺序为先时长后格式，例如 formatDuration .timeout "compact"：
+//   - go：Go 的默认形式，"1h30m0s"；
+//   - compact：省略为 0 的分钟和秒，"1h30m"，仍可被 parseDuration 解析；
+//   - seconds、milliseconds：向零取整的秒数或毫秒数，不带单位，"5400"；
+//   - iso8601：ISO 8601 时长，"PT1H30M"，最大单位为小时。
+func formatDuration(v interface{}, layout string) (string, error) {
+	d, err := toDuration(v)
+	if err != nil {
+		return "", err
+	}
+	switch layout {
+	case "go":
+		return d.String(), nil
+	case "compact":
+		s := d.String()
+		if strings.HasSuffix(s, "m0s") {
+			s = strings.TrimSuffix(s, "0s")
+		}
+		return strings.Replace(s, "h0m", "h", 1), nil
+	case "seconds":
+		return strconv.FormatInt(int64(d/time.Second), 10), nil
+	case "milliseconds":
+		return strconv.FormatInt(d.Milliseconds(), 10), nil
+	case "iso8601":
+		return isoDuration(d), nil
+	}
+	return "", fmt.Errorf("unsupported duration layout %q (expected go, compact, seconds, milliseconds or iso8601)", layout)
+}
+
+// isoDuration 将时长格式化为 ISO 8601 形式，负数时长加上 "-" 前缀
+func isoDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+	sign := ""
+	// 使用 uint64 计算绝对值，避免最小的负数取反后溢出
+	abs := uint64(d)
+	if d < 0 {
+		sign = "-"
+		abs = -abs
+	}
+
+	var b strings.Builder
+	b.WriteString(sign + "PT")
+	hours := abs / uint64(time.Hour)
+	minutes := abs % uint64(time.Hour) / uint64(time.Minute)
+	nanos := abs % uint64(time.Minute)
+	if hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+	}
+	if minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+	}
+	if nanos > 0 {
+		seconds := strconv.FormatFloat(float64(nanos)/float64(time.Second), 'f', -1, 64)
+		b.WriteString(seconds + "S")
+	}
+	return b.String()
+}
+
+// durationLt、durationGt 和 durationEq 比较两个时长，参数可以是时长字符串或 parseDuration 的结果，
+// 例如 durationGt .timeout "30s"
+func durationLt(a interface{}, b interface{}) (bool, error) {
+	return compareDurations(a, b, func(x, y time.Duration) bool { return x < y })
+}
+
+func durationGt(a interface{}, b interface{}) (bool, error) {
+	return compareDurations(a, b, func(x, y time.Duration) bool { return x > y })
+}
+
+func durationEq(a interface{}, b interface{}) (bool, error) {
+	return compareDurations(a, b, func(x, y time.Duration) bool { return x == y })
+}
+
+func compareDurations(a interface{}, b interface{}, cmp func(x, y time.Duration) bool) (bool, error) {
+	x, err := toDuration(a)
+	if err != nil {
+		return false, err
+	}
+	y, err := toDuration(b)
+	if err != nil {
+		return false, err
+	}
+	return cmp(x, y), nil
+}
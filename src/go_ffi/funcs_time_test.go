@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+// formatDuration 的参数顺序为先时长后格式，时长可以是字符串或 parseDuration 的结果
+func TestFormatDuration(t *testing.T) {
+	runTemplateCases(t, `{"timeout": "90m", "short": "1500ms", "neg": "-2h0m30s"}`, []templateCase{
+		{"go", `{{ formatDuration .timeout "go" }}`, "1h30m0s", false},
+		{"compact", `{{ formatDuration .timeout "compact" }}`, "1h30m", false},
+		{"compact hours", `{{ formatDuration "2h" "compact" }}`, "2h", false},
+		{"seconds", `{{ formatDuration .timeout "seconds" }}`, "5400", false},
+		{"seconds truncated", `{{ formatDuration .short "seconds" }}`, "1", false},
+		{"milliseconds", `{{ formatDuration .short "milliseconds" }}`, "1500", false},
+		{"iso8601", `{{ formatDuration .timeout "iso8601" }}`, "PT1H30M", false},
+		{"iso8601 fraction", `{{ formatDuration .short "iso8601" }}`, "PT1.5S", false},
+		{"iso8601 negative", `{{ formatDuration .neg "iso8601" }}`, "-PT2H30S", false},
+		{"iso8601 zero", `{{ formatDuration "0s" "iso8601" }}`, "PT0S", false},
+		{"parsed", `{{ formatDuration (parseDuration "45s") "milliseconds" }}`, "45000", false},
+		{"unknown layout", `{{ formatDuration .timeout "weeks" }}`, "", true},
+		{"invalid duration", `{{ formatDuration "soon" "go" }}`, "", true},
+		{"old argument order", `{{ formatDuration "compact" .timeout }}`, "", true},
+	})
+}
+
+// 时长的比较函数接受字符串和 parseDuration 的结果，非法时长为执行错误
+func TestDurationCompare(t *testing.T) {
+	runTemplateCases(t, `{"timeout": "1m"}`, []templateCase{
+		{"gt", `{{ durationGt .timeout "30s" }}`, "true", false},
+		{"lt", `{{ durationLt .timeout "30s" }}`, "false", false},
+		{"eq", `{{ durationEq .timeout "60s" }}`, "true", false},
+		{"parsed", `{{ durationEq (parseDuration "1h") "60m" }}`, "true", false},
+		{"invalid", `{{ durationGt .timeout "later" }}`, "", true},
+	})
+}
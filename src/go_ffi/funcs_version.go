@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// parseVersion 解析语义化版本号，允许省略次版本号和修订号（如 1.10）以及前缀 v
+func parseVersion(s string) (*semver.Version, error) {
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q", s)
+	}
+	return v, nil
+}
+
+// semverCompare 判断 version 是否满足 constraint，约束语法与 Sprig 相同，
+// 例如 ">=1.10.0"、"~1.2"、"^2"、">=1.2, <2.0" 或 "<1 || >=3"
+func semverCompare(constraint string, version string) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q", constraint)
+	}
+	v, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Check(v), nil
+}
+
+// versionPair 解析两个版本号，供 semverLt、semverGt 和 semverEq 使用
+func versionPair(a string, b string) (*semver.Version, *semver.Version, error) {
+	x, err := parseVersion(a)
+	if err != nil {
+		return nil, nil, err
+	}
+	y, err := parseVersion(b)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, y, nil
+}
+
+// semverLt 判断版本 a 是否早于 b
+func semverLt(a string, b string) (bool, error) {
+	x, y, err := versionPair(a, b)
+	if err != nil {
+		return false, err
+	}
+	return x.LessThan(y), nil
+}
+
+// semverGt 判断版本 a 是否晚于 b
+func semverGt(a string, b string) (bool, error) {
+	x, y, err := versionPair(a, b)
+	if err != nil {
+		return false, err
+	}
+	return x.GreaterThan(y), nil
+}
+
+// semverEq 判断两个版本是否相同，构建元数据（+ 之后的部分）不参与比较
+func semverEq(a string, b string) (bool, error) {
+	x, y, err := versionPair(a, b)
+	if err != nil {
+		return false, err
+	}
+	return x.Equal(y), nil
+}
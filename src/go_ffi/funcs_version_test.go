@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+)
+
+// semverCompare 使用 Sprig 的约束语法，版本号可以省略次版本号和修订号或带 v 前缀
+func TestSemverCompare(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=1.10.0", "1.10.0", true},
+		{">=1.10.0", "1.9.9", false},
+		{">=1.10.0", "v1.11", true},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"^2", "2.5.1", true},
+		{"^2", "3.0.0", false},
+		{">=1.2, <2.0", "1.5.0", true},
+		{">=1.2, <2.0", "2.0.0", false},
+		{"<1 || >=3", "0.9.0", true},
+		{"<1 || >=3", "2.0.0", false},
+		{">=1.2.0", "1.3.0-beta.1", false},
+		{">=1.3.0-alpha", "1.3.0-beta.1", true},
+	}
+	for _, tt := range tests {
+		got, err := semverCompare(tt.constraint, tt.version)
+		if err != nil {
+			t.Fatalf("semverCompare(%q, %q) failed: %v", tt.constraint, tt.version, err)
+		}
+		if got != tt.want {
+			t.Errorf("semverCompare(%q, %q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+// 预发布版本早于正式版本，构建元数据不参与比较
+func TestSemverOrdering(t *testing.T) {
+	tests := []struct {
+		a, b       string
+		lt, gt, eq bool
+	}{
+		{"1.2.3", "1.10.0", true, false, false},
+		{"1.10.0", "1.2.3", false, true, false},
+		{"1.2", "1.2.0", false, false, true},
+		{"v1.2.3", "1.2.3", false, false, true},
+		{"1.2.3-rc.1", "1.2.3", true, false, false},
+		{"1.2.3-alpha", "1.2.3-beta", true, false, false},
+		{"1.2.3+build.1", "1.2.3+build.2", false, false, true},
+	}
+	for _, tt := range tests {
+		if got, err := semverLt(tt.a, tt.b); err != nil || got != tt.lt {
+			t.Errorf("semverLt(%q, %q) = %v, %v, want %v", tt.a, tt.b, got, err, tt.lt)
+		}
+		if got, err := semverGt(tt.a, tt.b); err != nil || got != tt.gt {
+			t.Errorf("semverGt(%q, %q) = %v, %v, want %v", tt.a, tt.b, got, err, tt.gt)
+		}
+		if got, err := semverEq(tt.a, tt.b); err != nil || got != tt.eq {
+			t.Errorf("semverEq(%q, %q) = %v, %v, want %v", tt.a, tt.b, got, err, tt.eq)
+		}
+	}
+}
+
+// 无效的版本号和约束都是执行错误，错误信息指出出错的输入
+func TestSemverInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func() (bool, error)
+		want string
+	}{
+		{"empty version", func() (bool, error) { return semverLt("", "1.0.0") }, `invalid semantic version ""`},
+		{"garbage version", func() (bool, error) { return semverGt("1.0.0", "latest") }, `invalid semantic version "latest"`},
+		{"too many parts", func() (bool, error) { return semverEq("1.2.3.4", "1.2.3") }, `invalid semantic version "1.2.3.4"`},
+		{"bad version in compare", func() (bool, error) { return semverCompare(">=1.0", "x.y") }, `invalid semantic version "x.y"`},
+		{"bad constraint", func() (bool, error) { return semverCompare(">>1", "1.0.0") }, `invalid version constraint ">>1"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.fn()
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("got error %v, want %q", err, tt.want)
+			}
+		})
+	}
+	runTemplateCases(t, `{"v": "not-a-version"}`, []templateCase{
+		{"in template", `{{ semverLt .v "1.0.0" }}`, "", true},
+		{"valid in template", `{{ if semverCompare ">=1.2" "1.10.0" }}new{{ end }}`, "new", false},
+	})
+}
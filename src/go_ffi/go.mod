@@ -1,2 +1,14 @@
 module gotpl
 
+go 1.18
+
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/Masterminds/semver/v3 v3.2.1
+	github.com/tdewolff/minify/v2 v2.21.3
+	golang.org/x/text v0.22.0
+	google.golang.org/protobuf v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/tdewolff/parse/v2 v2.7.19 // indirect
@@ -0,0 +1,67 @@
+package main
+
+/*
+#include <stdlib.h> // For C.free
+
+typedef void (*log_callback_t)(int level, const char* message);
+
+// Go 无法直接调用 C 函数指针，需要通过一个 C 函数转发
+static inline void callLogCallback(log_callback_t cb, int level, const char* message) {
+    cb(level, message);
+}
+*/
+import "C"
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// 日志级别，与传给回调的 level 参数一致
+const (
+	logDebug = 0
+	logInfo  = 1
+	logWarn  = 2
+	logError = 3
+)
+
+var (
+	logMu sync.RWMutex
+	// logHandler 接收格式化后的日志，为 nil 时不产生日志
+	logHandler func(level int, message string)
+)
+
+// SetLogCallback 注册用于接收渲染诊断事件的 C 回调，传入 NULL 取消注册。
+// 回调可能在任意线程上被并发调用；message 在回调返回后即被释放，需要保留时请自行复制。
+// 未注册回调时不会产生任何日志。
+//
+//export SetLogCallback
+func SetLogCallback(cb C.log_callback_t) {
+	if cb == nil {
+		setLogHandler(nil)
+		return
+	}
+	setLogHandler(func(level int, message string) {
+		cMessage := C.CString(message)
+		defer C.free(unsafe.Pointer(cMessage))
+		C.callLogCallback(cb, C.int(level), cMessage)
+	})
+}
+
+// setLogHandler 替换接收日志的处理函数，SetLogCallback 用它注册包装后的 C 回调
+func setLogHandler(handler func(level int, message string)) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	logHandler = handler
+}
+
+// logf 向已注册的处理函数发送一条日志，未注册时直接返回，不会格式化消息
+func logf(level int, format string, args ...interface{}) {
+	logMu.RLock()
+	handler := logHandler
+	logMu.RUnlock()
+	if handler == nil {
+		return
+	}
+	handler(level, fmt.Sprintf(format, args...))
+}
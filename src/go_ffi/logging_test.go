@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+)
+
+// logEntry 是测试中收到的一条日志
+type logEntry struct {
+	level   int
+	message string
+}
+
+// captureLogs 在测试期间注册记录日志的处理函数，测试结束时取消注册
+func captureLogs(t *testing.T) *[]logEntry {
+	t.Helper()
+	var entries []logEntry
+	setLogHandler(func(level int, message string) {
+		entries = append(entries, logEntry{level, message})
+	})
+	t.Cleanup(func() { setLogHandler(nil) })
+	return &entries
+}
+
+// 渲染按阶段发送日志：解析和执行为 debug，成功为 info，超出限制为 warn，执行失败为 error
+func TestLogf(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     renderOptions
+		want     []logEntry
+	}{
+		{"success", `hi`, renderOptions{}, []logEntry{
+			{logDebug, "parsing template (2 bytes)"},
+			{logDebug, "executing Text template"},
+			{logInfo, "rendered 2 bytes"},
+		}},
+		{"html", `x`, renderOptions{EscapeHtml: true}, []logEntry{
+			{logDebug, "parsing template (1 bytes)"},
+			{logDebug, "executing HTML template"},
+			{logInfo, "rendered 1 bytes"},
+		}},
+		{"execution error", `{{ fail "boom" }}`, renderOptions{}, []logEntry{
+			{logDebug, "parsing template (17 bytes)"},
+			{logDebug, "executing Text template"},
+			{logError, `failed to execute Text template: template: goTemplate:1:3: executing "goTemplate" at <fail "boom">: error calling fail: boom`},
+		}},
+		{"output limit", `too long`, renderOptions{MaxOutputBytes: 3}, []logEntry{
+			{logDebug, "parsing template (8 bytes)"},
+			{logDebug, "executing Text template"},
+			{logWarn, "render limit hit: rendered output exceeds 3 bytes"},
+		}},
+		{"parse error", `{{ if }}`, renderOptions{}, []logEntry{
+			{logDebug, "parsing template (8 bytes)"},
+			{logError, "failed to parse Text template: template: goTemplate:1: missing value for if"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := captureLogs(t)
+			renderGoTemplate(tt.template, `{}`, tt.opts)
+			if len(*entries) != len(tt.want) {
+				t.Fatalf("got %d log entries %+v, want %+v", len(*entries), *entries, tt.want)
+			}
+			for i, want := range tt.want {
+				if (*entries)[i] != want {
+					t.Errorf("entry %d: got %+v, want %+v", i, (*entries)[i], want)
+				}
+			}
+		})
+	}
+}
+
+// 取消注册后不再收到日志
+func TestLogfWithoutHandler(t *testing.T) {
+	entries := captureLogs(t)
+	setLogHandler(nil)
+	if result := renderGoTemplate(`hi`, `{}`, renderOptions{}); result.Output != "hi" {
+		t.Fatalf("got %+v, want output %q", result, "hi")
+	}
+	if len(*entries) != 0 {
+		t.Errorf("got log entries %+v after unregistering", *entries)
+	}
+}
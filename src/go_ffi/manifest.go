@@ -0,0 +1,23 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// manifestEntry 记录一次成功渲染的产物：名称、输出的字节数以及输出的 SHA-256（十六进制）
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Length int    `json:"length"`
+	SHA256 string `json:"sha256"`
+}
+
+// newManifestEntry 为名为 name 的渲染结果 output 生成清单条目
+func newManifestEntry(name string, output string) manifestEntry {
+	sum := sha256.Sum256([]byte(output))
+	return manifestEntry{
+		Name:   name,
+		Length: len(output),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 开启 manifest 选项时，RenderAll 按名称列出成功渲染的模板，失败的模板不出现在清单中
+func TestRenderAllManifest(t *testing.T) {
+	result := renderAll(`{{ define "b" }}bb{{ end }}{{ define "a" }}a{{ end }}{{ define "c" }}{{ fail "boom" }}{{ end }}`, `{}`, renderOptions{Manifest: true})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var output manifestOutputs
+	if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+		t.Fatal(err)
+	}
+	want := []manifestEntry{newManifestEntry("a", "a"), newManifestEntry("b", "bb")}
+	if !reflect.DeepEqual(output.Manifest, want) {
+		t.Errorf("got manifest %+v, want %+v", output.Manifest, want)
+	}
+	if output.Outputs["c"].Error == "" {
+		t.Errorf("template %q should record its error in outputs", "c")
+	}
+}
+
+// RenderBatch 的清单条目以元素下标命名，回调模式下清单附加在汇总中
+func TestRenderBatchManifest(t *testing.T) {
+	const data = `[{"v": "x"}, 1, {"v": "yz"}]`
+	want := []manifestEntry{newManifestEntry("0", "x"), newManifestEntry("2", "yz")}
+
+	t.Run("collected", func(t *testing.T) {
+		result := renderBatch(`{{ .v }}`, strings.NewReader(data), renderOptions{Manifest: true}, nil)
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		var output batchManifestOutput
+		if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+			t.Fatal(err)
+		}
+		if len(output.Results) != 3 {
+			t.Errorf("got %d results, want 3", len(output.Results))
+		}
+		if !reflect.DeepEqual(output.Manifest, want) {
+			t.Errorf("got manifest %+v, want %+v", output.Manifest, want)
+		}
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		result := renderBatch(`{{ .v }}`, strings.NewReader(data), renderOptions{Manifest: true}, func(index int, result RenderResult) bool {
+			return true
+		})
+		if result.Error != "" {
+			t.Fatalf("unexpected error: %s", result.Error)
+		}
+		var output batchManifestSummary
+		if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+			t.Fatal(err)
+		}
+		if output.Count != 3 || output.Failed != 1 {
+			t.Errorf("got count %d failed %d, want 3 and 1", output.Count, output.Failed)
+		}
+		if !reflect.DeepEqual(output.Manifest, want) {
+			t.Errorf("got manifest %+v, want %+v", output.Manifest, want)
+		}
+	})
+}
+
+// RenderMatrix 的清单条目以组合在结果数组中的下标命名，失败的组合不出现在清单中
+func TestRenderMatrixManifest(t *testing.T) {
+	result := renderMatrix(`{{ failIf (eq .v "b") "boom" }}{{ .v }}`, `{"v": ["a", "b", "cd"]}`, renderOptions{Manifest: true})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var output matrixManifestOutput
+	if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Results) != 3 || output.Results[1].Error == "" {
+		t.Errorf("got results %+v, want 3 with the second failed", output.Results)
+	}
+	want := []manifestEntry{newManifestEntry("0", "a"), newManifestEntry("2", "cd")}
+	if !reflect.DeepEqual(output.Manifest, want) {
+		t.Errorf("got manifest %+v, want %+v", output.Manifest, want)
+	}
+}
+
+// 未开启 manifest 选项时输出格式保持不变
+func TestManifestDisabledByDefault(t *testing.T) {
+	result := renderAll(`{{ define "a" }}a{{ end }}`, `{}`, renderOptions{})
+	if strings.Contains(result.Output, "manifest") {
+		t.Errorf("RenderAll output %s should not contain a manifest", result.Output)
+	}
+	result = renderMatrix(`x`, `{"v": [1]}`, renderOptions{})
+	if !strings.HasPrefix(result.Output, "[") {
+		t.Errorf("RenderMatrix output %s should be a JSON array", result.Output)
+	}
+	result = renderBatch(`x`, strings.NewReader(`[{}]`), renderOptions{}, nil)
+	if !strings.HasPrefix(result.Output, "[") {
+		t.Errorf("RenderBatch output %s should be a JSON array", result.Output)
+	}
+}
+
+// newManifestEntry 记录字节数而不是字符数，哈希为十六进制的 SHA-256
+func TestNewManifestEntry(t *testing.T) {
+	tests := []struct {
+		output string
+		want   manifestEntry
+	}{
+		{"", manifestEntry{Name: "n", Length: 0, SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"}},
+		{"abc", manifestEntry{Name: "n", Length: 3, SHA256: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"}},
+		{"é", manifestEntry{Name: "n", Length: 2, SHA256: "4a99557e4033c3539de2eb65472017cad5f9557f7a0625a09f1c3f6e2ba69c4c"}},
+	}
+	for _, tt := range tests {
+		if got := newManifestEntry("n", tt.output); got != tt.want {
+			t.Errorf("newManifestEntry(%q) = %+v, want %+v", tt.output, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// renderOptions 汇总了一次渲染的全部选项。
+// RenderWithOptions 从 JSON 中解码这些选项，未知的键会被忽略，
+// 这样新增选项时无需修改导出函数的签名，旧的调用方也能继续工作。
+type renderOptions struct {
+	// EscapeHtml 为 true 时使用 html/template，否则使用 text/template
+	EscapeHtml bool `json:"escapeHtml"`
+	// MissingKey 对应 Go 模板的 missingkey 选项：default、zero 或 error，为空时使用 default
+	MissingKey string `json:"missingKey"`
+	// LeftDelim 和 RightDelim 为自定义的动作分隔符，为空时使用 {{ 和 }}
+	LeftDelim  string `json:"leftDelim"`
+	RightDelim string `json:"rightDelim"`
+	// TimeoutMs 为模板执行的超时时间（毫秒），0 表示不限制
+	TimeoutMs int64 `json:"timeoutMs"`
+	// MaxOutputBytes 为输出的最大字节数，超出时中止执行，0 表示不限制
+	MaxOutputBytes int64 `json:"maxOutputBytes"`
+	// PreviewBytes 大于 0 时，输出超过该字节数后立即停止渲染，按字符边界截断并追加 previewMarker，
+	// 用于快速预览大模板，0 表示不截断
+	PreviewBytes int `json:"previewBytes"`
+	// ExpectedSize 为预计的输出大小（字节），用于预先分配缓冲区以减少扩容，0 表示不预分配
+	ExpectedSize int `json:"expectedSize"`
+	// FailOnEmptyOutput 为 true 时，执行成功但输出为空会被视为错误
+	FailOnEmptyOutput bool `json:"failOnEmptyOutput"`
+	// LineNumbers 为 true 时，输出的每一行前都会加上行号，便于调试
+	LineNumbers bool `json:"lineNumbers"`
+	// RootKey 非空时，数据会被包装为 { rootKey: data }，便于复用 Helm 风格（.Values）的模板
+	RootKey string `json:"rootKey"`
+	// Minify 非空时（html、css、js、json），渲染结果会按该类型压缩
+	Minify string `json:"minify"`
+	// ReferenceTime 为 RFC3339 格式的参考时间，timeAgo 等函数用它代替当前时间，便于测试
+	ReferenceTime string `json:"referenceTime"`
+	// Preprocess 为渲染前按顺序应用到数据上的变换名称，见 dataTransforms
+	Preprocess []string `json:"preprocess"`
+	// MissingPlaceholder 非 nil 时，缺失的键和 null 值输出为该字符串而不是 "<no value>"，
+	// 使用指针以区分未设置与空字符串
+	MissingPlaceholder *string `json:"missingPlaceholder"`
+	// OutputEncoding 非空时，渲染结果会转换为该字符集，仅 RenderBytes 支持，
+	// 因为转换后的内容可能包含 NUL 字节，无法作为 C 字符串返回
+	OutputEncoding string `json:"outputEncoding"`
+	// ReplaceUnencodable 为 true 时，目标字符集无法表示的字符会被替换，否则视为错误
+	ReplaceUnencodable bool `json:"replaceUnencodable"`
+	// DataBaseDir 为 RenderFromDataFile 允许读取的目录，为空时拒绝读取任何数据文件
+	DataBaseDir string `json:"dataBaseDir"`
+	// MaxCombinations 为 RenderMatrix 允许的最大参数组合数，0 表示使用默认值 1000
+	MaxCombinations int `json:"maxCombinations"`
+	// LineEnding 为 lf 或 crlf 时，输出中的换行符会在最后一步统一为该形式，为空或 keep 时保持不变
+	LineEnding string `json:"lineEnding"`
+	// StrictFuncs 为 true 时，解析前会检查模板调用的所有函数，并在一条错误中列出全部未注册的函数
+	StrictFuncs bool `json:"strictFuncs"`
+	// PostFilters 为渲染后按顺序应用到输出上的过滤器名称，见 outputFilters
+	PostFilters []string `json:"postFilters"`
+	// EmbedChecksum 非空时（#、// 或 <!--），输出末尾会追加一行该格式的注释，记录输出的 SHA-256，
+	// 供 VerifyChecksum 校验内容是否被修改
+	EmbedChecksum string `json:"embedChecksum"`
+	// AllowRenderString 为 true 时注册 renderString。它会执行来自数据的模板文本，
+	// 因此默认不可用，只应在数据可信时开启
+	AllowRenderString bool `json:"allowRenderString"`
+	// Manifest 为 true 时，RenderAll、RenderMatrix 和 RenderBatch 的输出中附带 manifest 清单，
+	// 按渲染顺序列出每个成功渲染的单元的名称、输出字节数和 SHA-256
+	Manifest bool `json:"manifest"`
+	// Constants 为模板通过 const "NAME" 读取的命名常量，与数据分开传入
+	Constants map[string]interface{} `json:"constants"`
+}
+
+// parseRenderOptions 解码并校验 JSON 格式的渲染选项，空字符串表示全部使用默认值
+func parseRenderOptions(optionsJson string) (renderOptions, error) {
+	var opts renderOptions
+	if optionsJson != "" {
+		if err := json.Unmarshal([]byte(optionsJson), &opts); err != nil {
+			return opts, err
+		}
+	}
+
+	// Template.Option 遇到非法的 missingkey 值会 panic，因此需要提前校验
+	switch opts.MissingKey {
+	case "", "default", "invalid", "zero", "error":
+	default:
+		return opts, fmt.Errorf("invalid missingKey option %q (expected default, zero or error)", opts.MissingKey)
+	}
+	if opts.ReferenceTime != "" {
+		if _, err := time.Parse(time.RFC3339, opts.ReferenceTime); err != nil {
+			return opts, fmt.Errorf("invalid referenceTime option %q (expected RFC3339)", opts.ReferenceTime)
+		}
+	}
+	switch opts.LineEnding {
+	case "", "keep", "lf", "crlf":
+	default:
+		return opts, fmt.Errorf("invalid lineEnding option %q (expected lf, crlf or keep)", opts.LineEnding)
+	}
+	if opts.EmbedChecksum != "" {
+		if err := checkChecksumComment(opts.EmbedChecksum); err != nil {
+			return opts, err
+		}
+		// 截断的预览和转换字符集后的字节都与校验的内容不一致
+		if opts.PreviewBytes > 0 || opts.OutputEncoding != "" {
+			return opts, fmt.Errorf("embedChecksum cannot be combined with previewBytes or outputEncoding")
+		}
+	}
+	if err := checkTransforms(opts.Preprocess); err != nil {
+		return opts, err
+	}
+	if err := checkOutputFilters(opts.PostFilters); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// parseStringOptions 解码以 C 字符串返回结果的导出函数所使用的渲染选项，
+// 这些函数不支持 OutputEncoding
+func parseStringOptions(optionsJson string) (renderOptions, error) {
+	opts, err := parseRenderOptions(optionsJson)
+	if err != nil {
+		return opts, err
+	}
+	if opts.OutputEncoding != "" {
+		return opts, fmt.Errorf("outputEncoding is only supported by RenderBytes")
+	}
+	return opts, nil
+}
+
+// missingKeyOption 返回传给 Template.Option 的 missingkey 设置
+func (opts renderOptions) missingKeyOption() string {
+	if opts.MissingKey == "" {
+		return "missingkey=default"
+	}
+	return "missingkey=" + opts.MissingKey
+}
+
+// now 返回渲染使用的当前时间，设置了 ReferenceTime 时总是返回该时间
+func (opts renderOptions) now() time.Time {
+	if opts.ReferenceTime != "" {
+		if t, err := time.Parse(time.RFC3339, opts.ReferenceTime); err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tdewolff/minify/v2"
+	"github.com/tdewolff/minify/v2/css"
+	"github.com/tdewolff/minify/v2/html"
+	"github.com/tdewolff/minify/v2/js"
+	"github.com/tdewolff/minify/v2/json"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/ianaindex"
+)
+
+// minifyMediaTypes 是 minify 选项支持的内容类型
+var minifyMediaTypes = map[string]string{
+	"html": "text/html",
+	"css":  "text/css",
+	"js":   "application/javascript",
+	"json": "application/json",
+}
+
+// numberLines 在每一行前加上右对齐的行号，仅用于调试。
+// 输出末尾的换行符不会产生额外的空行号。
+func numberLines(s string) string {
+	if s == "" {
+		return s
+	}
+
+	trailingNewline := strings.HasSuffix(s, "\n")
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	width := len(strconv.Itoa(len(lines)))
+
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d | %s", width, i+1, line)
+		if i < len(lines)-1 || trailingNewline {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// minifyOutput 按内容类型（html、css、js、json）压缩渲染结果
+func minifyOutput(s string, contentType string) (string, error) {
+	mediaType, ok := minifyMediaTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported minify type %q", contentType)
+	}
+
+	m := minify.New()
+	m.AddFunc("text/html", html.Minify)
+	m.AddFunc("text/css", css.Minify)
+	m.AddFunc("application/javascript", js.Minify)
+	m.AddFunc("application/json", json.Minify)
+	return m.String(mediaType, s)
+}
+
+// encodeOutput 将 UTF-8 的渲染结果转换为 charset（IANA 名称或别名，如 ISO-8859-1、latin1、GBK）。
+// 目标字符集无法表示的字符在 replace 为 true 时替换为该字符集的替代字符（通常为 0x1A），
+// 否则返回指出第一个此类字符的错误。
+func encodeOutput(s string, charset string, replace bool) ([]byte, error) {
+	enc, err := ianaindex.IANA.Encoding(charset)
+	if err != nil || enc == nil {
+		return nil, fmt.Errorf("unsupported output encoding %q", charset)
+	}
+
+	encoder := enc.NewEncoder()
+	if replace {
+		encoder = encoding.ReplaceUnsupported(encoder)
+	}
+	out, err := encoder.Bytes([]byte(s))
+	if err == nil {
+		return out, nil
+	}
+
+	// 逐个字符重新编码，定位无法表示的字符以便报告
+	for offset, r := range s {
+		if _, runeErr := enc.NewEncoder().String(string(r)); runeErr != nil {
+			return nil, fmt.Errorf("character %q at byte %d cannot be represented in %s", r, offset, charset)
+		}
+	}
+	return nil, err
+}
+
+// normalizeLineEndings 将所有换行符（CRLF、单独的 CR 和 LF）统一为 ending 指定的形式（lf 或 crlf）。
+// 先统一为 LF 再转换，因此对已经是 CRLF 的内容重复执行不会产生 CRCRLF。
+func normalizeLineEndings(s string, ending string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	if ending == "crlf" {
+		s = strings.ReplaceAll(s, "\n", "\r\n")
+	}
+	return s
+}
+
+// outputFilters 列出 postFilters 选项支持的输出过滤器，按选项中给出的顺序依次应用
+var outputFilters = map[string]func(string) (string, error){
+	"trim": func(s string) (string, error) {
+		return strings.TrimSpace(s), nil
+	},
+	"trimTrailingSpace": func(s string) (string, error) {
+		return trimTrailingSpace(s), nil
+	},
+	"lf": func(s string) (string, error) {
+		return normalizeLineEndings(s, "lf"), nil
+	},
+	"crlf": func(s string) (string, error) {
+		return normalizeLineEndings(s, "crlf"), nil
+	},
+	"ensureTrailingNewline": func(s string) (string, error) {
+		if s == "" || strings.HasSuffix(s, "\n") {
+			return s, nil
+		}
+		return s + "\n", nil
+	},
+	"minifyHtml": func(s string) (string, error) {
+		return minifyOutput(s, "html")
+	},
+	"minifyCss": func(s string) (string, error) {
+		return minifyOutput(s, "css")
+	},
+	"minifyJs": func(s string) (string, error) {
+		return minifyOutput(s, "js")
+	},
+	"minifyJson": func(s string) (string, error) {
+		return minifyOutput(s, "json")
+	},
+}
+
+// checkOutputFilters 校验过滤器名称，遇到未知名称时返回的错误中包含该名称及其位置
+func checkOutputFilters(names []string) error {
+	for i, name := range names {
+		if _, ok := outputFilters[name]; !ok {
+			known := make([]string, 0, len(outputFilters))
+			for filter := range outputFilters {
+				known = append(known, filter)
+			}
+			sort.Strings(known)
+			return fmt.Errorf("unknown post filter %q at index %d (expected one of %s)", name, i, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// applyOutputFilters 按顺序对输出应用过滤器，名称需已通过 checkOutputFilters 校验
+func applyOutputFilters(s string, names []string) (string, error) {
+	for i, name := range names {
+		var err error
+		if s, err = outputFilters[name](s); err != nil {
+			return "", fmt.Errorf("post filter %q at index %d: %v", name, i, err)
+		}
+	}
+	return s, nil
+}
+
+// trimTrailingSpace 去掉每一行末尾的空格和制表符，换行符保持不变
+func trimTrailingSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		cr := strings.HasSuffix(line, "\r")
+		line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " \t")
+		if cr {
+			line += "\r"
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"text/template/parse"
+)
+
+// missingPlaceholderFunc 是追加到输出动作末尾的内部函数名，
+// 下划线开头以避免与内置函数或用户数据中的名称冲突
+const missingPlaceholderFunc = "_gotpl_missing_placeholder"
+
+// placeholderFunc 返回替换缺失值的函数。
+// 参数为 reflect.Value，这样缺失的键（无效值）和 JSON null 都能被识别，
+// 而不会像 interface{} 参数那样在传入前被转换为 nil。
+func placeholderFunc(placeholder string) func(reflect.Value) interface{} {
+	return func(v reflect.Value) interface{} {
+		if v.IsValid() && v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if !v.IsValid() {
+			return placeholder
+		}
+		return v.Interface()
+	}
+}
+
+// guardMissingValues 在每个输出动作的管道末尾（html、urlquery 之前）追加 missingPlaceholderFunc，
+// 字段取值、变量和函数调用（如 index .m "missing"）得到的无效值或 nil 接口都会被替换。
+// 与在输出中替换 "<no value>" 不同，这样不会影响数据或模板文本中原样出现的 "<no value>"。
+// 带变量声明的动作不产生输出，保持不变。
+func guardMissingValues(root *parse.ListNode) {
+	walkTree(root, func(node parse.Node) {
+		action, ok := node.(*parse.ActionNode)
+		if !ok || len(action.Pipe.Decl) > 0 {
+			return
+		}
+		appendPipeCommand(action.Pipe, parse.NewIdentifier(missingPlaceholderFunc).SetPos(action.Pos))
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+)
+
+// 缺失的值和 null 无论来自字段、变量还是函数调用都会被替换为占位符，数据中原样出现的 "<no value>" 保持不变
+func TestMissingPlaceholder(t *testing.T) {
+	placeholder := "N/A"
+	tests := []struct {
+		name       string
+		template   string
+		escapeHtml bool
+		want       string
+	}{
+		{"missing field", `{{ .missing }}`, false, "N/A"},
+		{"null field", `{{ .null }}`, false, "N/A"},
+		{"nested field", `{{ .m.missing }}`, false, "N/A"},
+		{"variable", `{{ $v := .missing }}{{ $v }}`, false, "N/A"},
+		{"chain", `{{ (.m).missing }}`, false, "N/A"},
+		{"before html", `{{ .missing | html }}`, false, "N/A"},
+		{"html template", `<p>{{ .missing }}</p>`, true, "<p>N/A</p>"},
+		{"present", `{{ .name }}`, false, "x"},
+		{"literal no value", `{{ .literal }}`, false, "<no value>"},
+		{"index call", `{{ index .m "missing" }}`, false, "N/A"},
+		{"index null", `{{ index . "null" }}`, false, "N/A"},
+		{"function nil result", `{{ ifNil .missing .null }}`, false, "N/A"},
+		{"index before html", `<p>{{ index .m "missing" }}</p>`, true, "<p>N/A</p>"},
+		{"index present", `{{ index . "name" }}`, false, "x"},
+		{"literal from index", `{{ index . "literal" }}`, false, "<no value>"},
+		{"function result", `{{ printf "%v" .missing }}`, false, "<nil>"},
+		{"zero value", `{{ len .m }}`, false, "0"},
+		{"declaration", `{{ $v := .missing }}`, false, ""},
+		{"inside range", `{{ range .items }}[{{ .id }}]{{ end }}`, false, "[1][N/A]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := renderOptions{MissingPlaceholder: &placeholder, EscapeHtml: tt.escapeHtml}
+			result := renderGoTemplate(tt.template, `{"name": "x", "null": null, "literal": "<no value>", "m": {}, "items": [{"id": 1}, {}]}`, opts)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			if result.Output != tt.want {
+				t.Errorf("got %q, want %q", result.Output, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dataTransforms 列出 preprocess 选项支持的数据变换，每个变换都会递归处理嵌套的 map 和列表
+var dataTransforms = map[string]func(interface{}) interface{}{
+	"lowercaseKeys": lowercaseKeys,
+	"trimStrings":   trimStrings,
+	"removeNulls":   removeNulls,
+}
+
+// checkTransforms 校验变换名称，遇到未知名称时返回的错误中包含该名称及其位置
+func checkTransforms(names []string) error {
+	for i, name := range names {
+		if _, ok := dataTransforms[name]; !ok {
+			return fmt.Errorf("unknown preprocess transform %q at index %d (expected lowercaseKeys, trimStrings or removeNulls)", name, i)
+		}
+	}
+	return nil
+}
+
+// applyTransforms 按顺序对解码后的数据执行变换，名称需已通过 checkTransforms 校验
+func applyTransforms(data interface{}, names []string) interface{} {
+	for _, name := range names {
+		data = dataTransforms[name](data)
+	}
+	return data
+}
+
+// lowercaseKeys 将所有 map 的键转换为小写。
+// 多个键转换后相同时按原键的字典序处理，后者覆盖前者，因此原本就是小写的键总会保留。
+func lowercaseKeys(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make(map[string]interface{}, len(val))
+		for _, k := range keys {
+			out[strings.ToLower(k)] = lowercaseKeys(val[k])
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = lowercaseKeys(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// trimStrings 去掉所有字符串值首尾的空白，map 的键保持不变
+func trimStrings(v interface{}) interface{} {
+	switch val := v.(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = trimStrings(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = trimStrings(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// removeNulls 删除 map 中值为 null 的键以及列表中的 null 元素
+func removeNulls(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if item != nil {
+				out[k] = removeNulls(item)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(val))
+		for _, item := range val {
+			if item != nil {
+				out = append(out, removeNulls(item))
+			}
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protoFiles 保存通过 RegisterProtoDescriptors 注册的 .proto 文件，可被多个线程同时访问
+var protoFiles = struct {
+	sync.RWMutex
+	files *protoregistry.Files
+}{files: &protoregistry.Files{}}
+
+// registerProtoDescriptors 注册序列化的 FileDescriptorSet（protoc --descriptor_set_out --include_imports 的输出）。
+// 文件需要按依赖顺序排列，依赖也可以是之前注册过的文件；已注册的同名文件会被跳过。
+func registerProtoDescriptors(descriptorSet []byte) RenderResult {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(descriptorSet, &set); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal FileDescriptorSet: %v", err),
+		}
+	}
+
+	protoFiles.Lock()
+	defer protoFiles.Unlock()
+	for _, fdp := range set.File {
+		if _, err := protoFiles.files.FindFileByPath(fdp.GetName()); err == nil {
+			continue
+		}
+		fd, err := protodesc.NewFile(fdp, protoFiles.files)
+		if err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to build descriptor for %q: %v", fdp.GetName(), err),
+			}
+		}
+		if err := protoFiles.files.RegisterFile(fd); err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to register %q: %v", fdp.GetName(), err),
+			}
+		}
+	}
+	return RenderResult{}
+}
+
+// decodeProtoData 按已注册的消息类型（完整名称，如 "pkg.Message"）解码 protobuf 数据，
+// 转换为以字段名为键的 map，然后与 JSON 数据一样应用 opts 中的预处理和 rootKey
+func decodeProtoData(data []byte, messageType string, opts renderOptions) (interface{}, error) {
+	protoFiles.RLock()
+	desc, err := protoFiles.files.FindDescriptorByName(protoreflect.FullName(messageType))
+	protoFiles.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf("message type %q is not registered", messageType)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%q is not a message type", messageType)
+	}
+
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return prepareData(protoMessageMap(msg), opts), nil
+}
+
+// protoMessageMap 将消息转换为以 .proto 中的字段名为键的 map。
+// 与 proto3 的 JSON 编码一样输出未设置的标量字段的默认值，使模板中的字段访问不会得到 <no value>；
+// 未设置的消息字段为 nil，oneof 中只包含已设置的字段。
+func protoMessageMap(msg protoreflect.Message) map[string]interface{} {
+	fields := msg.Descriptor().Fields()
+	out := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.ContainingOneof() != nil && !msg.Has(fd) {
+			continue
+		}
+		if fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !msg.Has(fd) {
+			out[string(fd.Name())] = nil
+			continue
+		}
+		out[string(fd.Name())] = protoFieldValue(fd, msg.Get(fd))
+	}
+	return out
+}
+
+func protoFieldValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		list := v.List()
+		out := make([]interface{}, list.Len())
+		for i := range out {
+			out[i] = protoScalarValue(fd, list.Get(i))
+		}
+		return out
+	case fd.IsMap():
+		out := map[string]interface{}{}
+		v.Map().Range(func(key protoreflect.MapKey, value protoreflect.Value) bool {
+			out[toString(key.Interface())] = protoScalarValue(fd.MapValue(), value)
+			return true
+		})
+		return out
+	}
+	return protoScalarValue(fd, v)
+}
+
+// protoScalarValue 转换单个值：整数保持为 int64 或 uint64，不会像 JSON 数字一样损失精度；
+// 枚举使用其名称（未知的值使用数字），bytes 与 JSON 编码一样使用 base64
+func protoScalarValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoMessageMap(v.Message())
+	case protoreflect.EnumKind:
+		if value := fd.Enum().Values().ByNumber(v.Enum()); value != nil {
+			return string(value.Name())
+		}
+		return int64(v.Enum())
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return v.Uint()
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	}
+	return v.Interface()
+}
+
+// renderProto 使用 protobuf 数据渲染模板，选项与 RenderWithOptions 相同
+func renderProto(templateContent string, data []byte, messageType string, opts renderOptions) RenderResult {
+	decoded, err := decodeProtoData(data, messageType, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal protobuf data: %v", err),
+		}
+	}
+	return executeGoTemplate(templateContent, decoded, opts)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// testProtoFile 描述测试使用的 gotpl.test 包，覆盖标量、枚举、bytes、repeated、map、嵌套消息和 oneof
+func testProtoFile() *descriptorpb.FileDescriptorProto {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, typeName string, repeated bool) *descriptorpb.FieldDescriptorProto {
+		label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+		if repeated {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+		}
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     typ.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	oneof := func(f *descriptorpb.FieldDescriptorProto) *descriptorpb.FieldDescriptorProto {
+		f.OneofIndex = proto.Int32(0)
+		return f
+	}
+	const (
+		tString  = descriptorpb.FieldDescriptorProto_TYPE_STRING
+		tInt64   = descriptorpb.FieldDescriptorProto_TYPE_INT64
+		tInt32   = descriptorpb.FieldDescriptorProto_TYPE_INT32
+		tUint32  = descriptorpb.FieldDescriptorProto_TYPE_UINT32
+		tDouble  = descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+		tBool    = descriptorpb.FieldDescriptorProto_TYPE_BOOL
+		tBytes   = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+		tEnum    = descriptorpb.FieldDescriptorProto_TYPE_ENUM
+		tMessage = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+	)
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("gotpl/test/sample.proto"),
+		Package: proto.String("gotpl.test"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Color"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("COLOR_UNSPECIFIED"), Number: proto.Int32(0)},
+				{Name: proto.String("RED"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name:  proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{field("name", 1, tString, "", false)},
+			},
+			{
+				Name: proto.String("Sample"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, tString, "", false),
+					field("big", 2, tInt64, "", false),
+					field("count", 3, tUint32, "", false),
+					field("ratio", 4, tDouble, "", false),
+					field("ok", 5, tBool, "", false),
+					field("blob", 6, tBytes, "", false),
+					field("color", 7, tEnum, ".gotpl.test.Color", false),
+					field("tags", 8, tString, "", true),
+					field("scores", 9, tMessage, ".gotpl.test.Sample.ScoresEntry", true),
+					field("item", 10, tMessage, ".gotpl.test.Item", false),
+					field("items", 11, tMessage, ".gotpl.test.Item", true),
+					oneof(field("text", 12, tString, "", false)),
+					oneof(field("number", 13, tInt32, "", false)),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("ScoresEntry"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						field("key", 1, tString, "", false),
+						field("value", 2, tInt32, "", false),
+					},
+					Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				}},
+				OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("choice")}},
+			},
+		},
+	}
+}
+
+// registerTestProto 注册 testProtoFile，重复注册会被跳过，因此每个测试都可以调用
+func registerTestProto(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{testProtoFile()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := registerProtoDescriptors(set); result.Error != "" {
+		t.Fatalf("register failed: %s", result.Error)
+	}
+	protoFiles.RLock()
+	desc, err := protoFiles.files.FindDescriptorByName("gotpl.test.Sample")
+	protoFiles.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return desc.(protoreflect.MessageDescriptor)
+}
+
+// newTestSample 按字段名设置 Sample 消息的字段并序列化
+func newTestSample(t *testing.T, md protoreflect.MessageDescriptor, set func(msg *dynamicpb.Message, field func(string) protoreflect.FieldDescriptor)) []byte {
+	t.Helper()
+	msg := dynamicpb.NewMessage(md)
+	set(msg, func(name string) protoreflect.FieldDescriptor {
+		return md.Fields().ByName(protoreflect.Name(name))
+	})
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// 解码后整数保持 int64/uint64，枚举使用名称，bytes 为 base64，map 和 repeated 字段转换为 map 和列表
+func TestDecodeProtoData(t *testing.T) {
+	md := registerTestProto(t)
+	item := md.Fields().ByName("item").Message()
+	data := newTestSample(t, md, func(msg *dynamicpb.Message, field func(string) protoreflect.FieldDescriptor) {
+		msg.Set(field("name"), protoreflect.ValueOfString("x"))
+		msg.Set(field("big"), protoreflect.ValueOfInt64(1<<60))
+		msg.Set(field("count"), protoreflect.ValueOfUint32(7))
+		msg.Set(field("ratio"), protoreflect.ValueOfFloat64(0.5))
+		msg.Set(field("ok"), protoreflect.ValueOfBool(true))
+		msg.Set(field("blob"), protoreflect.ValueOfBytes([]byte{0xff, 0x00}))
+		msg.Set(field("color"), protoreflect.ValueOfEnum(1))
+		tags := msg.Mutable(field("tags")).List()
+		tags.Append(protoreflect.ValueOfString("a"))
+		tags.Append(protoreflect.ValueOfString("b"))
+		msg.Mutable(field("scores")).Map().Set(protoreflect.ValueOfString("k").MapKey(), protoreflect.ValueOfInt32(3))
+		sub := dynamicpb.NewMessage(item)
+		sub.Set(item.Fields().ByName("name"), protoreflect.ValueOfString("child"))
+		msg.Set(field("item"), protoreflect.ValueOfMessage(sub))
+		msg.Mutable(field("items")).List().Append(protoreflect.ValueOfMessage(sub))
+		msg.Set(field("number"), protoreflect.ValueOfInt32(42))
+	})
+
+	got, err := decodeProtoData(data, "gotpl.test.Sample", renderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := map[string]interface{}{"name": "child"}
+	want := map[string]interface{}{
+		"name":   "x",
+		"big":    int64(1 << 60),
+		"count":  uint64(7),
+		"ratio":  0.5,
+		"ok":     true,
+		"blob":   "/wA=",
+		"color":  "RED",
+		"tags":   []interface{}{"a", "b"},
+		"scores": map[string]interface{}{"k": int64(3)},
+		"item":   child,
+		"items":  []interface{}{child},
+		"number": int64(42),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+// 未设置的标量字段为默认值，未设置的消息字段为 nil，未设置的 oneof 不出现，未知的枚举值使用数字
+func TestDecodeProtoDataDefaults(t *testing.T) {
+	md := registerTestProto(t)
+	data := newTestSample(t, md, func(msg *dynamicpb.Message, field func(string) protoreflect.FieldDescriptor) {
+		msg.Set(field("color"), protoreflect.ValueOfEnum(9))
+	})
+
+	got, err := decodeProtoData(data, "gotpl.test.Sample", renderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name":   "",
+		"big":    int64(0),
+		"count":  uint64(0),
+		"ratio":  0.0,
+		"ok":     false,
+		"blob":   "",
+		"color":  int64(9),
+		"tags":   []interface{}{},
+		"scores": map[string]interface{}{},
+		"item":   nil,
+		"items":  []interface{}{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+// 以 protobuf 数据渲染模板，rootKey 等选项与 JSON 数据相同
+func TestRenderProto(t *testing.T) {
+	md := registerTestProto(t)
+	data := newTestSample(t, md, func(msg *dynamicpb.Message, field func(string) protoreflect.FieldDescriptor) {
+		msg.Set(field("name"), protoreflect.ValueOfString("x"))
+		msg.Set(field("big"), protoreflect.ValueOfInt64(9007199254740993))
+		msg.Set(field("text"), protoreflect.ValueOfString("chosen"))
+	})
+
+	result := renderProto(`{{ .msg.name }} {{ .msg.big }} {{ .msg.text }} {{ .msg.color }}`, data, "gotpl.test.Sample", renderOptions{RootKey: "msg"})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if want := "x 9007199254740993 chosen COLOR_UNSPECIFIED"; result.Output != want {
+		t.Errorf("got %q, want %q", result.Output, want)
+	}
+}
+
+// 未注册的类型、不是消息的名称和无法解码的数据都返回错误
+func TestDecodeProtoDataErrors(t *testing.T) {
+	registerTestProto(t)
+	tests := []struct {
+		name        string
+		data        []byte
+		messageType string
+		wantErr     string
+	}{
+		{"unregistered", nil, "gotpl.test.Missing", `message type "gotpl.test.Missing" is not registered`},
+		{"enum", nil, "gotpl.test.Color", `"gotpl.test.Color" is not a message type`},
+		{"invalid data", []byte{0xff}, "gotpl.test.Sample", "cannot parse invalid wire-format data"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeProtoData(tt.data, tt.messageType, renderOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			result := renderProto(`x`, tt.data, tt.messageType, renderOptions{})
+			if !strings.HasPrefix(result.Error, "Failed to unmarshal protobuf data: ") {
+				t.Errorf("renderProto: got error %q", result.Error)
+			}
+		})
+	}
+}
+
+// 无效的描述符集合和缺少依赖的文件在注册时报告
+func TestRegisterProtoDescriptorsErrors(t *testing.T) {
+	missingDep := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("gotpl/test/broken.proto"),
+		Package:    proto.String("gotpl.broken"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"gotpl/test/missing.proto"},
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{missingDep}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"not a descriptor set", []byte{0xff}, "Failed to unmarshal FileDescriptorSet: "},
+		{"missing dependency", set, `Failed to build descriptor for "gotpl/test/broken.proto": `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := registerProtoDescriptors(tt.data); !strings.HasPrefix(result.Error, tt.wantErr) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+)
+
+// namedOutput 是 RenderAll 中单个模板的渲染结果
+type namedOutput struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// manifestOutputs 是开启 manifest 选项时 RenderAll 的输出
+type manifestOutputs struct {
+	Outputs  map[string]namedOutput `json:"outputs"`
+	Manifest []manifestEntry        `json:"manifest"`
+}
+
+// renderAll 解析一次模板源码，然后用同一份数据依次渲染其中每个非空的模板。
+// 单个模板失败不会影响其他模板，错误记录在该模板的结果中；
+// 只有数据解码或解析失败时才返回整体错误。开启 manifest 选项时输出为 manifestOutputs，
+// 清单按模板名称排序，只包含渲染成功的模板。
+func renderAll(templateContent string, jsonData string, opts renderOptions) RenderResult {
+	data, err := decodeTemplateData(jsonData, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal JSON data: %v", err),
+		}
+	}
+
+	set, kind, err := parseTemplateSet(templateContent, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse %s template: %v", kind, err),
+		}
+	}
+
+	outputs := make(map[string]namedOutput)
+	manifest := []manifestEntry{}
+	for _, name := range definedTemplates(set.tmpl) {
+		// 每个模板都是一次独立的渲染，计数器不会延续到下一个模板
+		var result RenderResult
+		result, set = set.renderOnce(kind, name, data)
+		outputs[name] = namedOutput{Output: result.Output, Error: result.Error}
+		if opts.Manifest && result.Error == "" {
+			manifest = append(manifest, newManifestEntry(name, result.Output))
+		}
+	}
+
+	var encoded string
+	if opts.Manifest {
+		encoded, err = encodeJSON(manifestOutputs{Outputs: outputs, Manifest: manifest})
+	} else {
+		encoded, err = encodeJSON(outputs)
+	}
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
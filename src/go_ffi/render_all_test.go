@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// renderAllOutputs 调用 renderAll 并解码各模板的结果，整体出错时测试失败
+func renderAllOutputs(t *testing.T, templateContent string, jsonData string, opts renderOptions) map[string]namedOutput {
+	t.Helper()
+	result := renderAll(templateContent, jsonData, opts)
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var outputs map[string]namedOutput
+	if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+		t.Fatal(err)
+	}
+	return outputs
+}
+
+// 每个非空的模板都会被渲染，包括根模板；空白的模板被跳过
+func TestRenderAllTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     map[string]namedOutput
+	}{
+		{
+			"defines only",
+			`{{ define "b" }}B{{ .v }}{{ end }}{{ define "a" }}A{{ .v }}{{ end }}`,
+			map[string]namedOutput{"a": {Output: "A1"}, "b": {Output: "B1"}},
+		},
+		{
+			"with root",
+			`root{{ define "a" }}A{{ end }}`,
+			map[string]namedOutput{rootTemplateName: {Output: "root"}, "a": {Output: "A"}},
+		},
+		{
+			"blank define skipped",
+			`{{ define "a" }}A{{ end }}{{ define "blank" }}  {{ end }}`,
+			map[string]namedOutput{"a": {Output: "A"}},
+		},
+		{
+			"template calls",
+			`{{ define "a" }}[{{ template "b" . }}]{{ end }}{{ define "b" }}{{ .v }}{{ end }}`,
+			map[string]namedOutput{"a": {Output: "[1]"}, "b": {Output: "1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderAllOutputs(t, tt.template, `{"v": 1}`, renderOptions{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 输出对象的键按模板名称排序，与定义顺序无关
+func TestRenderAllNameOrder(t *testing.T) {
+	result := renderAll(`{{ define "c" }}c{{ end }}{{ define "a" }}a{{ end }}{{ define "b" }}b{{ end }}`, `{}`, renderOptions{})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var positions []int
+	for _, name := range []string{"a", "b", "c"} {
+		positions = append(positions, strings.Index(result.Output, `"`+name+`":`))
+	}
+	if !sort.IntsAreSorted(positions) || positions[0] < 0 {
+		t.Errorf("templates not in name order: %s", result.Output)
+	}
+}
+
+// 单个模板失败只记录在该模板的结果中，其他模板照常渲染
+func TestRenderAllPerTemplateError(t *testing.T) {
+	got := renderAllOutputs(t, `{{ define "a" }}A{{ end }}{{ define "bad" }}x{{ fail "boom" }}{{ end }}{{ define "c" }}C{{ end }}`, `{}`, renderOptions{})
+	if got["a"] != (namedOutput{Output: "A"}) || got["c"] != (namedOutput{Output: "C"}) {
+		t.Errorf("healthy templates affected by failure: %+v", got)
+	}
+	if got["bad"].Error == "" || !strings.Contains(got["bad"].Error, "boom") {
+		t.Errorf("got %+v for failing template, want its error", got["bad"])
+	}
+}
+
+// 数据解码和解析失败时返回整体错误
+func TestRenderAllErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     string
+		want     string
+	}{
+		{"bad data", `{{ define "a" }}A{{ end }}`, `{`, "Failed to unmarshal JSON data: "},
+		{"bad template", `{{ define "a" }}{{ .v `, `{}`, "Failed to parse Text template: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderAll(tt.template, tt.data, renderOptions{})
+			if !strings.HasPrefix(result.Error, tt.want) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.want)
+			}
+		})
+	}
+}
+
+// slowCounterTemplate 在不产生输出的嵌套循环中反复调用 next，超时后执行它的 goroutine 会一直运行到循环结束
+const slowCounterTemplate = `{{ range $.n }}{{ range $.n }}{{ $_ := next "c" }}{{ end }}{{ end }}`
+
+// slowCounterData 返回 slowCounterTemplate 使用的数据，循环次数远超测试使用的超时时间
+func slowCounterData() string {
+	return `{"n": [` + strings.Repeat("0,", 1499) + `0]}`
+}
+
+// 超时的模板在后台继续执行，之后的模板不能与它共用计数器（go test -race 可以发现共用时的竞争）
+func TestRenderAllAfterTimeout(t *testing.T) {
+	var template strings.Builder
+	for _, name := range []string{"a", "b", "c", "d"} {
+		template.WriteString(`{{ define "` + name + `" }}` + slowCounterTemplate + `{{ end }}`)
+	}
+	template.WriteString(`{{ define "z" }}{{ next "c" }}{{ next "c" }}{{ end }}`)
+
+	got := renderAllOutputs(t, template.String(), slowCounterData(), renderOptions{TimeoutMs: 50})
+	for _, name := range []string{"a", "b", "c", "d"} {
+		if !strings.Contains(got[name].Error, "timed out") {
+			t.Errorf("template %q: got %+v, want a timeout", name, got[name])
+		}
+	}
+	if got["z"] != (namedOutput{Output: "12"}) {
+		t.Errorf("template %q: got %+v, want output %q", "z", got["z"], "12")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// batchOutput 是未注册回调时 RenderBatch 中单个元素的渲染结果
+type batchOutput struct {
+	Index  int    `json:"index"`
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// batchSummary 是注册了回调时 RenderBatch 的输出，Stopped 表示回调要求提前结束
+type batchSummary struct {
+	Count   int  `json:"count"`
+	Failed  int  `json:"failed"`
+	Stopped bool `json:"stopped"`
+}
+
+// batchManifestOutput 和 batchManifestSummary 是开启 manifest 选项时 RenderBatch 的输出，
+// 清单条目的名称为元素在数组中的下标
+type batchManifestOutput struct {
+	Results  []batchOutput   `json:"results"`
+	Manifest []manifestEntry `json:"manifest"`
+}
+
+type batchManifestSummary struct {
+	batchSummary
+	Manifest []manifestEntry `json:"manifest"`
+}
+
+// renderBatch 从 r 中逐个解码 JSON 数组的元素，每解码一个就渲染一次模板，模板只解析一次，
+// 因此内存占用与数组长度无关。write 非 nil 时每个结果都交给 write，write 返回 false 时停止，
+// 输出为 batchSummary；write 为 nil 时结果收集为 batchOutput 组成的 JSON 数组。
+// 开启 manifest 选项时两者分别改为 batchManifestSummary 和 batchManifestOutput。
+// 不是对象的元素和渲染失败只记录在该元素的结果中；JSON 语法错误使后续元素无法定位，会中止整个批次。
+func renderBatch(templateContent string, r io.Reader, opts renderOptions, write func(index int, result RenderResult) bool) RenderResult {
+	set, kind, err := parseTemplateSet(templateContent, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse %s template: %v", kind, err),
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+		return RenderResult{
+			Error: "Failed to decode batch data: expected a JSON array",
+		}
+	}
+
+	var (
+		outputs  []batchOutput
+		summary  batchSummary
+		manifest = []manifestEntry{}
+	)
+	if write == nil {
+		outputs = []batchOutput{}
+	}
+	for index := 0; dec.More(); index++ {
+		var element interface{}
+		if err := dec.Decode(&element); err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to decode element %d: %v", index, err),
+			}
+		}
+
+		var result RenderResult
+		if data, ok := element.(map[string]interface{}); ok {
+			result, set = set.renderOnce(kind, "", prepareData(data, opts))
+		} else {
+			result = RenderResult{
+				Error: fmt.Sprintf("Failed to decode element %d: expected a JSON object, got %T", index, element),
+			}
+		}
+
+		summary.Count++
+		if result.Error != "" {
+			summary.Failed++
+		} else if opts.Manifest {
+			manifest = append(manifest, newManifestEntry(strconv.Itoa(index), result.Output))
+		}
+		if write == nil {
+			outputs = append(outputs, batchOutput{Index: index, Output: result.Output, Error: result.Error})
+		} else if !write(index, result) {
+			summary.Stopped = true
+			break
+		}
+	}
+	if !summary.Stopped {
+		// 读取结尾的 "]"，并与 json.Unmarshal 一样拒绝数组之后多余的内容
+		if _, err := dec.Token(); err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to decode batch data: %v", err),
+			}
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			return RenderResult{
+				Error: "Failed to decode batch data: unexpected data after the array",
+			}
+		}
+	}
+
+	var encoded string
+	switch {
+	case write == nil && opts.Manifest:
+		encoded, err = encodeJSON(batchManifestOutput{Results: outputs, Manifest: manifest})
+	case write == nil:
+		encoded, err = encodeJSON(outputs)
+	case opts.Manifest:
+		encoded, err = encodeJSON(batchManifestSummary{batchSummary: summary, Manifest: manifest})
+	default:
+		encoded, err = encodeJSON(summary)
+	}
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 未注册回调时，每个元素的结果按顺序收集，非对象元素的错误只记录在该元素中并带上下标
+func TestRenderBatchCollected(t *testing.T) {
+	result := renderBatch(`{{ .name }}`, strings.NewReader(`[{"name": "a"}, "oops", {"name": "b"}]`), renderOptions{}, nil)
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var outputs []batchOutput
+	if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+		t.Fatal(err)
+	}
+	want := []batchOutput{
+		{Index: 0, Output: "a"},
+		{Index: 1, Error: "Failed to decode element 1: expected a JSON object, got string"},
+		{Index: 2, Output: "b"},
+	}
+	if !reflect.DeepEqual(outputs, want) {
+		t.Errorf("got %+v, want %+v", outputs, want)
+	}
+}
+
+// 回调返回 false 时立即停止，之后的元素不会被解码，即使其中有语法错误
+func TestRenderBatchStopsWhenCallbackDeclines(t *testing.T) {
+	var indexes []int
+	result := renderBatch(`{{ .n }}`, strings.NewReader(`[{"n": 1}, {"n": 2}, {"n": 3}, not json`), renderOptions{}, func(index int, result RenderResult) bool {
+		indexes = append(indexes, index)
+		return index < 1
+	})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if !reflect.DeepEqual(indexes, []int{0, 1}) {
+		t.Errorf("callback saw indexes %v, want [0 1]", indexes)
+	}
+	var summary batchSummary
+	if err := json.Unmarshal([]byte(result.Output), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if want := (batchSummary{Count: 2, Stopped: true}); summary != want {
+		t.Errorf("got summary %+v, want %+v", summary, want)
+	}
+}
+
+// countingReader 记录已被读取的字节数，用于确认数组是按元素流式解码的
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	// 每次只读一个字节，避免 json.Decoder 的缓冲一次读完整个输入
+	if len(p) > 1 {
+		p = p[:1]
+	}
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+// 渲染第一个元素时，数组的其余部分尚未被读取
+func TestRenderBatchStreamsElements(t *testing.T) {
+	first := `[{"n": 1}`
+	input := &countingReader{r: strings.NewReader(first + strings.Repeat(`, {"n": 2}`, 1000) + "]")}
+	var readAtFirst int
+	result := renderBatch(`{{ .n }}`, input, renderOptions{}, func(index int, result RenderResult) bool {
+		readAtFirst = input.read
+		return false
+	})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	// Decoder 需要多读一个字节才能判断元素已经结束
+	if readAtFirst > len(first)+2 {
+		t.Errorf("read %d bytes before rendering the first element, want at most %d", readAtFirst, len(first)+2)
+	}
+}
+
+// 整体错误：输入不是数组、元素语法错误（报告下标）、数组之后有多余内容
+func TestRenderBatchErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"not an array", `{"n": 1}`, "Failed to decode batch data: expected a JSON array"},
+		{"empty input", ``, "Failed to decode batch data: expected a JSON array"},
+		{"syntax error", `[{"n": 1}, {"n": }]`, "Failed to decode element 1: "},
+		{"unterminated", `[{"n": 1}`, "Failed to decode element 1: "},
+		{"trailing data", `[{"n": 1}] {}`, "Failed to decode batch data: unexpected data after the array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderBatch(`{{ .n }}`, strings.NewReader(tt.input), renderOptions{}, nil)
+			if !strings.HasPrefix(result.Error, tt.want) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.want)
+			}
+			if result.Output != "" {
+				t.Errorf("got output %q, want none", result.Output)
+			}
+		})
+	}
+}
+
+// 空数组渲染零次，输出为空数组而不是 null
+func TestRenderBatchEmpty(t *testing.T) {
+	result := renderBatch(`x`, strings.NewReader(` [ ] `), renderOptions{}, nil)
+	if result.Error != "" || result.Output != "[]" {
+		t.Errorf("got %+v, want output %q", result, "[]")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// chainOutput 是 RenderWithDataChain 的输出，Index 为实际使用的数据候选的下标（从 0 开始）
+type chainOutput struct {
+	Index  int    `json:"index"`
+	Output string `json:"output"`
+}
+
+// decodeDataCandidates 解码数据候选的 JSON 数组。每个元素可以是包含 JSON 文档的字符串，
+// 也可以直接是数据对象；null 表示该来源不存在。
+func decodeDataCandidates(candidatesJson string) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(candidatesJson), &raw); err != nil {
+		return nil, err
+	}
+	candidates := make([]string, len(raw))
+	for i, element := range raw {
+		if strings.HasPrefix(strings.TrimSpace(string(element)), `"`) {
+			if err := json.Unmarshal(element, &candidates[i]); err != nil {
+				return nil, fmt.Errorf("candidate %d: %v", i, err)
+			}
+			continue
+		}
+		candidates[i] = string(element)
+	}
+	return candidates, nil
+}
+
+// renderWithDataChain 按顺序检查数据候选，使用第一个非空且能解码为对象的候选渲染模板。
+// 空字符串、null 和空对象视为空；与合并不同，只使用一个候选，之后的候选不会被读取。
+// 选中的候选渲染失败时直接返回该错误，不会再尝试后面的候选。
+func renderWithDataChain(templateContent string, candidatesJson string, opts renderOptions) RenderResult {
+	candidates, err := decodeDataCandidates(candidatesJson)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to decode data candidates: %v", err),
+		}
+	}
+
+	reasons := make([]string, 0, len(candidates))
+	for i, candidate := range candidates {
+		if strings.TrimSpace(candidate) == "" {
+			reasons = append(reasons, fmt.Sprintf("candidate %d: empty", i))
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(candidate), &data); err != nil {
+			reasons = append(reasons, fmt.Sprintf("candidate %d: %v", i, err))
+			continue
+		}
+		if len(data) == 0 {
+			reasons = append(reasons, fmt.Sprintf("candidate %d: empty", i))
+			continue
+		}
+
+		logf(logDebug, "using data candidate %d", i)
+		result := executeGoTemplate(templateContent, prepareData(data, opts), opts)
+		if result.Error != "" {
+			return result
+		}
+		encoded, err := encodeJSON(chainOutput{Index: i, Output: result.Output})
+		if err != nil {
+			return RenderResult{
+				Error: fmt.Sprintf("Failed to encode render results: %v", err),
+			}
+		}
+		return RenderResult{
+			Output: encoded,
+		}
+	}
+
+	if len(candidates) == 0 {
+		return RenderResult{
+			Error: "No usable data candidate: the candidate list is empty",
+		}
+	}
+	return RenderResult{
+		Error: "No usable data candidate: " + strings.Join(reasons, "; "),
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// 使用第一个非空且能解码为对象的候选，空的、null 和无效的候选被跳过，之后的候选不会被使用
+func TestRenderWithDataChain(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates string
+		want       chainOutput
+	}{
+		{"first wins", `[{"v": "a"}, {"v": "b"}]`, chainOutput{Index: 0, Output: "a"}},
+		{"string documents", `["{\"v\": \"a\"}", "{\"v\": \"b\"}"]`, chainOutput{Index: 0, Output: "a"}},
+		{"skip null", `[null, {"v": "b"}]`, chainOutput{Index: 1, Output: "b"}},
+		{"skip empty string", `["", "  ", {"v": "c"}]`, chainOutput{Index: 2, Output: "c"}},
+		{"skip empty object", `[{}, "{}", {"v": "c"}]`, chainOutput{Index: 2, Output: "c"}},
+		{"skip invalid document", `["{\"v\":", {"v": "b"}]`, chainOutput{Index: 1, Output: "b"}},
+		{"skip non-object", `[[1, 2], "\"text\"", 3, {"v": "d"}]`, chainOutput{Index: 3, Output: "d"}},
+		{"no merge with later candidates", `[{"other": 1}, {"v": "b"}]`, chainOutput{Index: 0, Output: "<no value>"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderWithDataChain(`{{ .v }}`, tt.candidates, renderOptions{})
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			var got chainOutput
+			if err := json.Unmarshal([]byte(result.Output), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 没有可用的候选时列出每个候选被跳过的原因；选中的候选渲染失败时直接返回错误
+func TestRenderWithDataChainErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		template   string
+		candidates string
+		wantErr    []string
+	}{
+		{"all unusable", `x`, `[null, "", "{\"v\":", [1]]`, []string{
+			"No usable data candidate: ",
+			"candidate 0: empty; candidate 1: empty; candidate 2: unexpected end of JSON input; candidate 3: json: cannot unmarshal array",
+		}},
+		{"empty list", `x`, `[]`, []string{"No usable data candidate: the candidate list is empty"}},
+		{"not an array", `x`, `{"v": 1}`, []string{"Failed to decode data candidates: "}},
+		{"invalid array", `x`, `[{"v": 1}`, []string{"Failed to decode data candidates: "}},
+		{"selected candidate fails", `{{ fail "boom" }}`, `[{"v": 1}, {"v": 2}]`, []string{templateFailurePrefix + "boom"}},
+		{"parse error", `{{ if }}`, `[{"v": 1}]`, []string{"Failed to parse Text template: "}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderWithDataChain(tt.template, tt.candidates, renderOptions{})
+			if result.Output != "" {
+				t.Errorf("got output %q, want none", result.Output)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(result.Error, want) {
+					t.Errorf("error %q does not contain %q", result.Error, want)
+				}
+			}
+		})
+	}
+}
+
+// 选中的候选与普通数据一样应用 rootKey 等选项
+func TestRenderWithDataChainOptions(t *testing.T) {
+	result := renderWithDataChain(`{{ .data.v }}`, `[null, {"v": 1}]`, renderOptions{RootKey: "data"})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	if want := `{"index":1,"output":"1"}`; result.Output != want {
+		t.Errorf("got %s, want %s", result.Output, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// defaultMaxCombinations 是未设置 maxCombinations 选项时 RenderMatrix 允许的最大组合数
+const defaultMaxCombinations = 1000
+
+// matrixOutput 是 RenderMatrix 中单个参数组合的渲染结果
+type matrixOutput struct {
+	Params map[string]interface{} `json:"params"`
+	Output string                 `json:"output"`
+	Error  string                 `json:"error,omitempty"`
+}
+
+// matrixManifestOutput 是开启 manifest 选项时 RenderMatrix 的输出，清单条目的名称为组合在结果数组中的下标
+type matrixManifestOutput struct {
+	Results  []matrixOutput  `json:"results"`
+	Manifest []manifestEntry `json:"manifest"`
+}
+
+// decodeMatrix 解码参数矩阵（变量名到取值数组的对象），返回排序后的变量名、各变量的取值和组合总数。
+// 组合数超过 limit 时立即返回错误，不会先生成全部组合。
+func decodeMatrix(matrixJson string, limit int) ([]string, [][]interface{}, int, error) {
+	var matrix map[string]interface{}
+	if err := json.Unmarshal([]byte(matrixJson), &matrix); err != nil {
+		return nil, nil, 0, err
+	}
+
+	names := make([]string, 0, len(matrix))
+	for name := range matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	values := make([][]interface{}, len(names))
+	total := 1
+	for i, name := range names {
+		list, ok := matrix[name].([]interface{})
+		if !ok {
+			return nil, nil, 0, fmt.Errorf("values for %q must be an array, got %T", name, matrix[name])
+		}
+		values[i] = list
+		total *= len(list)
+		if total > limit {
+			return nil, nil, 0, fmt.Errorf("matrix has more than %d combinations", limit)
+		}
+	}
+	return names, values, total, nil
+}
+
+// renderMatrix 对参数矩阵的笛卡尔积中的每个组合渲染一次模板，模板只解析一次。
+// 组合按变量名排序后生成，最后一个变量变化最快；输出为 matrixOutput 组成的 JSON 数组。
+// 单个组合失败只记录在该组合的结果中。开启 manifest 选项时输出为 matrixManifestOutput。
+func renderMatrix(templateContent string, matrixJson string, opts renderOptions) RenderResult {
+	limit := opts.MaxCombinations
+	if limit <= 0 {
+		limit = defaultMaxCombinations
+	}
+	names, values, total, err := decodeMatrix(matrixJson, limit)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to decode matrix: %v", err),
+		}
+	}
+
+	set, kind, err := parseTemplateSet(templateContent, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse %s template: %v", kind, err),
+		}
+	}
+
+	outputs := make([]matrixOutput, 0, total)
+	manifest := []manifestEntry{}
+	indices := make([]int, len(names))
+	for n := 0; n < total; n++ {
+		params := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			params[name] = values[i][indices[i]]
+		}
+		var result RenderResult
+		result, set = set.renderOnce(kind, "", prepareData(params, opts))
+		outputs = append(outputs, matrixOutput{Params: params, Output: result.Output, Error: result.Error})
+		if opts.Manifest && result.Error == "" {
+			manifest = append(manifest, newManifestEntry(strconv.Itoa(n), result.Output))
+		}
+
+		// 像里程表一样推进下标，最后一个变量变化最快
+		for i := len(indices) - 1; i >= 0; i-- {
+			indices[i]++
+			if indices[i] < len(values[i]) {
+				break
+			}
+			indices[i] = 0
+		}
+	}
+
+	var encoded string
+	if opts.Manifest {
+		encoded, err = encodeJSON(matrixManifestOutput{Results: outputs, Manifest: manifest})
+	} else {
+		encoded, err = encodeJSON(outputs)
+	}
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 组合按变量名排序后生成，最后一个变量变化最快；任一变量的取值为空时没有组合
+func TestRenderMatrixCombinations(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		want   []string
+	}{
+		{"sorted names, last fastest", `{"b": [1, 2], "a": ["x", "y"]}`, []string{"x1", "x2", "y1", "y2"}},
+		{"three axes", `{"a": ["x"], "b": [1, 2], "c": [true, false]}`, []string{"x1true", "x1false", "x2true", "x2false"}},
+		{"single value", `{"a": ["x"], "b": [1]}`, []string{"x1"}},
+		{"empty axis", `{"a": ["x", "y"], "b": []}`, []string{}},
+		{"no axes", `{}`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderMatrix(`{{ range . }}{{ . }}{{ end }}`, tt.matrix, renderOptions{})
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			var outputs []matrixOutput
+			if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(outputs))
+			for i, output := range outputs {
+				got[i] = output.Output
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 单个组合失败只记录在该组合的结果中，params 保留该组合的取值
+func TestRenderMatrixPerCombinationError(t *testing.T) {
+	result := renderMatrix(`{{ if eq .n "b" }}{{ fail "b" }}{{ end }}{{ .n }}`, `{"n": ["a", "b", "c"]}`, renderOptions{})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var outputs []matrixOutput
+	if err := json.Unmarshal([]byte(result.Output), &outputs); err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 3 {
+		t.Fatalf("got %d combinations, want 3", len(outputs))
+	}
+	if outputs[0].Output != "a" || outputs[2].Output != "c" {
+		t.Errorf("got outputs %q and %q, want %q and %q", outputs[0].Output, outputs[2].Output, "a", "c")
+	}
+	if outputs[1].Error == "" || outputs[1].Params["n"] != "b" {
+		t.Errorf("combination 1 = %+v, want an error with params n=b", outputs[1])
+	}
+}
+
+// 矩阵本身无效时返回整体错误
+func TestRenderMatrixErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		matrix string
+		opts   renderOptions
+		want   string
+	}{
+		{"not an object", `[1, 2]`, renderOptions{}, "Failed to decode matrix: "},
+		{"non-array axis", `{"a": [1], "b": "x"}`, renderOptions{}, `Failed to decode matrix: values for "b" must be an array, got string`},
+		{"object axis", `{"a": {"x": 1}}`, renderOptions{}, `Failed to decode matrix: values for "a" must be an array, got map[string]interface {}`},
+		{"too many", `{"a": [1, 2, 3], "b": [1, 2]}`, renderOptions{MaxCombinations: 5}, "Failed to decode matrix: matrix has more than 5 combinations"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderMatrix(`x`, tt.matrix, tt.opts)
+			if !strings.HasPrefix(result.Error, tt.want) {
+				t.Errorf("got error %q, want prefix %q", result.Error, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"fmt"
+	"text/template/parse"
+)
+
+// numericFuncs 是参数应为数字的函数，传给它们的字段在示例数据中使用数字
+var numericFuncs = map[string]bool{
+	"add": true, "sub": true, "mul": true, "div": true, "mod": true,
+	"max": true, "min": true, "round": true,
+	"lt": true, "le": true, "gt": true, "ge": true,
+}
+
+// sampleNode 描述模板对数据中某个值的用法，用于生成示例数据。
+// 被访问过字段的值生成对象，被 range 遍历的值生成单元素数组，其余生成占位值。
+type sampleNode struct {
+	name   string
+	fields map[string]*sampleNode
+	elem   *sampleNode
+	number bool
+	// parent 为所属的对象或被遍历的值，isElem 表示该节点是 parent 的元素而不是字段
+	parent *sampleNode
+	isElem bool
+}
+
+func newSampleNode(name string) *sampleNode {
+	return &sampleNode{name: name}
+}
+
+// field 返回名为 name 的子字段，不存在时创建
+func (n *sampleNode) field(name string) *sampleNode {
+	if n.fields == nil {
+		n.fields = map[string]*sampleNode{}
+	}
+	child, ok := n.fields[name]
+	if !ok {
+		child = &sampleNode{name: name, parent: n}
+		n.fields[name] = child
+	}
+	return child
+}
+
+// element 返回被 range 遍历时的元素，元素的占位值沿用字段名
+func (n *sampleNode) element() *sampleNode {
+	if n.elem == nil {
+		n.elem = &sampleNode{name: n.name, parent: n, isElem: true}
+	}
+	return n.elem
+}
+
+// value 生成示例值：数组优先于对象，对象优先于占位值
+func (n *sampleNode) value() interface{} {
+	switch {
+	case n.elem != nil:
+		return []interface{}{n.elem.value()}
+	case len(n.fields) > 0:
+		obj := make(map[string]interface{}, len(n.fields))
+		for name, field := range n.fields {
+			obj[name] = field.value()
+		}
+		return obj
+	case n.number:
+		// 使用 1 而不是 0，避免 div、mod 的除数为零
+		return 1
+	default:
+		return n.name
+	}
+}
+
+// rootedAt 判断节点是否由 root 逐级访问得到，而不是来自无法分析的值（例如函数的返回值）
+func (n *sampleNode) rootedAt(root *sampleNode) bool {
+	for node := n; node != nil; node = node.parent {
+		if node == root {
+			return true
+		}
+	}
+	return false
+}
+
+// sampleScope 是遍历时的上下文：dot 为 . 指向的值，vars 为当前可见的变量
+type sampleScope struct {
+	dot  *sampleNode
+	vars map[string]*sampleNode
+}
+
+// with 返回 dot 替换后的新上下文，变量在新的作用域中复制一份，块内声明的变量不会泄漏到外部
+func (s sampleScope) with(dot *sampleNode) sampleScope {
+	vars := make(map[string]*sampleNode, len(s.vars))
+	for name, node := range s.vars {
+		vars[name] = node
+	}
+	return sampleScope{dot: dot, vars: vars}
+}
+
+// sampleCollector 遍历模板的语法树，记录每个被引用的数据路径
+type sampleCollector struct {
+	trees map[string]*parse.Tree
+	// active 为正在遍历的模板，用于跳过递归调用
+	active map[string]bool
+	// refs 非 nil 时记录每个字段访问节点解析到的数据节点，同一模板以不同数据调用时会有多个
+	refs map[parse.Node][]*sampleNode
+}
+
+// walkTemplate 以 dot 为数据遍历指定名称的模板
+func (c *sampleCollector) walkTemplate(name string, dot *sampleNode, root *sampleNode) {
+	tree, ok := c.trees[name]
+	if !ok || tree.Root == nil || c.active[name] {
+		return
+	}
+	c.active[name] = true
+	defer delete(c.active, name)
+	c.walkList(tree.Root, sampleScope{dot: dot, vars: map[string]*sampleNode{"$": root}})
+}
+
+func (c *sampleCollector) walkList(list *parse.ListNode, scope sampleScope) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		c.walkNode(node, scope)
+	}
+}
+
+func (c *sampleCollector) walkNode(node parse.Node, scope sampleScope) {
+	switch n := node.(type) {
+	case *parse.ActionNode:
+		c.walkPipe(n.Pipe, scope)
+	case *parse.IfNode:
+		c.walkPipe(n.Pipe, scope)
+		c.walkList(n.List, scope.with(scope.dot))
+		c.walkList(n.ElseList, scope.with(scope.dot))
+	case *parse.WithNode:
+		inner := scope.with(scope.dot)
+		value := c.walkPipe(n.Pipe, inner)
+		if value == nil {
+			value = newSampleNode("")
+		}
+		inner.dot = value
+		c.walkList(n.List, inner)
+		c.walkList(n.ElseList, scope.with(scope.dot))
+	case *parse.RangeNode:
+		inner := scope.with(scope.dot)
+		value := c.pipeValue(n.Pipe, inner)
+		elem := newSampleNode("")
+		if value != nil {
+			elem = value.element()
+		}
+		// range $e := 和 range $i, $e := 中，最后一个变量为元素
+		if decl := n.Pipe.Decl; len(decl) > 0 {
+			inner.vars[decl[len(decl)-1].Ident[0]] = elem
+		}
+		inner.dot = elem
+		c.walkList(n.List, inner)
+		c.walkList(n.ElseList, scope.with(scope.dot))
+	case *parse.TemplateNode:
+		dot := newSampleNode("")
+		if n.Pipe != nil {
+			if value := c.walkPipe(n.Pipe, scope); value != nil {
+				dot = value
+			}
+		}
+		c.walkTemplate(n.Name, dot, scope.vars["$"])
+	}
+}
+
+// walkPipe 记录管道中引用的数据路径，管道的值为单个数据路径时返回该路径的节点，否则返回 nil
+func (c *sampleCollector) walkPipe(pipe *parse.PipeNode, scope sampleScope) *sampleNode {
+	if pipe == nil {
+		return nil
+	}
+	value := c.pipeValue(pipe, scope)
+	for _, v := range pipe.Decl {
+		scope.vars[v.Ident[0]] = value
+	}
+	return value
+}
+
+// pipeValue 与 walkPipe 相同，但不绑定管道声明的变量
+func (c *sampleCollector) pipeValue(pipe *parse.PipeNode, scope sampleScope) *sampleNode {
+	var value *sampleNode
+	for _, cmd := range pipe.Cmds {
+		value = c.walkCommand(cmd, scope)
+	}
+	return value
+}
+
+// walkCommand 记录命令中引用的数据路径，命令只有一个数据路径参数时返回其节点
+func (c *sampleCollector) walkCommand(cmd *parse.CommandNode, scope sampleScope) *sampleNode {
+	args := make([]*sampleNode, len(cmd.Args))
+	for i, arg := range cmd.Args {
+		args[i] = c.walkArg(arg, scope)
+	}
+	if len(cmd.Args) == 1 {
+		return args[0]
+	}
+
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return nil
+	}
+	if numericFuncs[ident.Ident] {
+		for _, arg := range args[1:] {
+			if arg != nil {
+				arg.number = true
+			}
+		}
+	}
+	// include "name" data 与 template 动作相同，以 data 遍历被引用的模板
+	if (ident.Ident == "include" || ident.Ident == "includeOrEmpty") && len(cmd.Args) == 3 {
+		if name, ok := cmd.Args[1].(*parse.StringNode); ok && args[2] != nil {
+			c.walkTemplate(name.Text, args[2], scope.vars["$"])
+		}
+	}
+	return nil
+}
+
+// walkArg 返回参数引用的数据路径的节点，参数不是数据路径时返回 nil
+func (c *sampleCollector) walkArg(arg parse.Node, scope sampleScope) *sampleNode {
+	node := c.resolveArg(arg, scope)
+	if c.refs != nil && node != nil {
+		switch arg.(type) {
+		case *parse.FieldNode, *parse.VariableNode, *parse.ChainNode:
+			c.refs[arg] = append(c.refs[arg], node)
+		}
+	}
+	return node
+}
+
+func (c *sampleCollector) resolveArg(arg parse.Node, scope sampleScope) *sampleNode {
+	switch n := arg.(type) {
+	case *parse.DotNode:
+		return scope.dot
+	case *parse.FieldNode:
+		return sampleFields(scope.dot, n.Ident)
+	case *parse.VariableNode:
+		base := scope.vars[n.Ident[0]]
+		if base == nil {
+			return nil
+		}
+		return sampleFields(base, n.Ident[1:])
+	case *parse.ChainNode:
+		base := c.walkArg(n.Node, scope)
+		if base == nil {
+			return nil
+		}
+		return sampleFields(base, n.Field)
+	case *parse.PipeNode:
+		return c.walkPipe(n, scope.with(scope.dot))
+	}
+	return nil
+}
+
+func sampleFields(base *sampleNode, idents []string) *sampleNode {
+	node := base
+	for _, ident := range idents {
+		node = node.field(ident)
+	}
+	return node
+}
+
+// generateSampleData 根据模板引用的数据路径生成示例数据：
+// 字段访问生成对象，range 遍历的值生成单元素数组，数学和比较函数的参数生成数字 1，
+// 其余的值生成与字段名相同的字符串。template、include 调用的模板会以传入的数据继续分析。
+func generateSampleData(templateContent string) RenderResult {
+	trees, err := parseTrees(templateContent, "", "")
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template: %v", err),
+		}
+	}
+
+	root := newSampleNode("")
+	collector := &sampleCollector{trees: trees, active: map[string]bool{}}
+	collector.walkTemplate(rootTemplateName, root, root)
+
+	data := root.value()
+	if _, ok := data.(map[string]interface{}); !ok {
+		// 根数据总是对象，即使模板只把 . 作为整体使用
+		data = map[string]interface{}{}
+	}
+	encoded, err := encodeJSON(data)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode sample data: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
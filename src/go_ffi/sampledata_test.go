@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// 示例数据的形状跟随模板的用法：字段访问生成对象，range 生成单元素数组，数学函数的参数生成数字，
+// 递归调用的模板不再展开
+func TestGenerateSampleData(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{"field", `{{ .name }}`, `{"name": "name"}`},
+		{"nested", `{{ .user.address.city }}`, `{"user": {"address": {"city": "city"}}}`},
+		{"sibling fields", `{{ .user.name }} {{ .user.age }}`, `{"user": {"name": "name", "age": "age"}}`},
+		{"range", `{{ range .items }}{{ .id }}{{ end }}`, `{"items": [{"id": "id"}]}`},
+		{"range scalar", `{{ range .tags }}{{ . }}{{ end }}`, `{"tags": ["tags"]}`},
+		{"range variable", `{{ range $i, $e := .rows }}{{ $e.cell }}{{ end }}`, `{"rows": [{"cell": "cell"}]}`},
+		{"nested range", `{{ range .groups }}{{ range .members }}{{ .name }}{{ end }}{{ end }}`, `{"groups": [{"members": [{"name": "name"}]}]}`},
+		{"root in range", `{{ range .items }}{{ $.title }}{{ end }}`, `{"items": ["items"], "title": "title"}`},
+		{"with", `{{ with .user }}{{ .name }}{{ end }}`, `{"user": {"name": "name"}}`},
+		{"if", `{{ if .show }}{{ .body }}{{ end }}`, `{"show": "show", "body": "body"}`},
+		{"variable", `{{ $u := .user }}{{ $u.name }}`, `{"user": {"name": "name"}}`},
+		{"chain", `{{ (.user).name }}`, `{"user": {"name": "name"}}`},
+		{"numeric", `{{ add .count 1 }} {{ if gt .total 3 }}x{{ end }}`, `{"count": 1, "total": 1}`},
+		{"function argument", `{{ slugify .name }}`, `{"name": "name"}`},
+		{"template call", `{{ define "row" }}{{ .id }}{{ end }}{{ template "row" .item }}`, `{"item": {"id": "id"}}`},
+		{"include", `{{ define "row" }}{{ .id }}{{ end }}{{ include "row" .item }}`, `{"item": {"id": "id"}}`},
+		{"recursive template", `{{ define "t" }}{{ .name }}{{ template "t" .child }}{{ end }}{{ template "t" . }}`, `{"name": "name", "child": "child"}`},
+		{"dot only", `{{ . }}`, `{}`},
+		{"no data", `plain text`, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateSampleData(tt.template)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal([]byte(result.Output), &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %s, want %s", result.Output, tt.want)
+			}
+		})
+	}
+}
+
+// 语法错误的模板返回错误
+func TestGenerateSampleDataParseError(t *testing.T) {
+	if result := generateSampleData(`{{ if }}`); result.Error == "" {
+		t.Errorf("got output %q, want a parse error", result.Output)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/template/parse"
+)
+
+// renderSession 是一次交互式渲染的状态：每次渲染遇到缺失的键时报告其路径，
+// 调用方补充该值后重新渲染，直到渲染成功
+type renderSession struct {
+	mu              sync.Mutex
+	templateContent string
+	opts            renderOptions
+	data            interface{}
+	// root 为分析模板得到的根数据节点，fieldRefs 以错误信息中的位置（name:line:col）为键，
+	// 记录该位置的字段访问可能解析到的数据节点
+	root      *sampleNode
+	fieldRefs map[string][]*sampleNode
+}
+
+// sessionOutput 是会话相关导出函数的输出，done 为 false 时 missingPath 为需要补充的键的路径，
+// 路径中的字符串为 map 的键，数字为列表的下标
+type sessionOutput struct {
+	Session     int64         `json:"session"`
+	Done        bool          `json:"done"`
+	Output      string        `json:"output"`
+	MissingPath []interface{} `json:"missingPath,omitempty"`
+}
+
+// renderSessions 保存所有未结束的会话，可被多个线程同时访问
+var renderSessions = struct {
+	sync.Mutex
+	next     int64
+	sessions map[int64]*renderSession
+}{sessions: map[int64]*renderSession{}}
+
+// beginRenderSession 创建会话并进行第一次渲染。
+// 缺失的键总是视为错误（missingKey 选项被忽略），其余选项与 RenderWithOptions 相同。
+func beginRenderSession(templateContent string, jsonData string, opts renderOptions) RenderResult {
+	opts.MissingKey = "error"
+	data, err := decodeTemplateData(jsonData, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal JSON data: %v", err),
+		}
+	}
+
+	trees, err := parseTrees(templateContent, opts.LeftDelim, opts.RightDelim)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse template: %v", err),
+		}
+	}
+	root := newSampleNode("")
+	collector := &sampleCollector{trees: trees, active: map[string]bool{}, refs: map[parse.Node][]*sampleNode{}}
+	collector.walkTemplate(rootTemplateName, root, root)
+
+	session := &renderSession{
+		templateContent: templateContent,
+		opts:            opts,
+		data:            data,
+		root:            root,
+		fieldRefs:       map[string][]*sampleNode{},
+	}
+	for node, refs := range collector.refs {
+		// 语法树节点记录了所属的树，因此可以用任意一棵树计算位置
+		location, _ := trees[rootTemplateName].ErrorContext(node)
+		session.fieldRefs[location] = append(session.fieldRefs[location], refs...)
+	}
+
+	renderSessions.Lock()
+	renderSessions.next++
+	id := renderSessions.next
+	renderSessions.sessions[id] = session
+	renderSessions.Unlock()
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	return session.render(id)
+}
+
+// provideKey 将 value（JSON）写入会话数据中 path（JSON 数组）指定的位置，然后重新渲染。
+// 路径中间缺失的对象会被创建。
+func provideKey(id int64, pathJson string, valueJson string) RenderResult {
+	renderSessions.Lock()
+	session, ok := renderSessions.sessions[id]
+	renderSessions.Unlock()
+	if !ok {
+		return RenderResult{
+			Error: fmt.Sprintf("render session %d does not exist", id),
+		}
+	}
+
+	var path []interface{}
+	if err := json.Unmarshal([]byte(pathJson), &path); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse key path: %v", err),
+		}
+	}
+	if len(path) == 0 {
+		return RenderResult{
+			Error: "key path must not be empty",
+		}
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(valueJson), &value); err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal JSON value: %v", err),
+		}
+	}
+
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	data, err := setPath(session.data, path, value)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to set key: %v", err),
+		}
+	}
+	session.data = data
+	return session.render(id)
+}
+
+// endRenderSession 删除会话，会话已经结束或不存在时不做任何操作
+func endRenderSession(id int64) {
+	renderSessions.Lock()
+	delete(renderSessions.sessions, id)
+	renderSessions.Unlock()
+}
+
+// render 用当前数据渲染一次。渲染成功，或出现缺失键以外的错误时会话随之结束；
+// 调用方需要持有 s.mu。
+func (s *renderSession) render(id int64) RenderResult {
+	// 超时后执行中的 goroutine 可能仍在读取数据，之后 provideKey 修改同一份数据会导致并发读写 map，
+	// 因此每次渲染都使用数据的副本
+	result := executeGoTemplate(s.templateContent, deepCopy(s.data), s.opts)
+	output := sessionOutput{Session: id, Done: result.Error == "", Output: result.Output}
+	if !output.Done {
+		path, ok := s.missingPath(result.Error)
+		if !ok {
+			endRenderSession(id)
+			return result
+		}
+		output.MissingPath = path
+	} else {
+		endRenderSession(id)
+	}
+
+	encoded, err := encodeJSON(output)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
+
+// missingPath 从执行错误中找出缺失的键在数据中的路径。
+// Go 模板只报告缺失的键名和出错的字段访问所在的位置，因此先按位置找到该字段访问对应的数据节点，
+// 再在数据中按执行顺序（列表按下标、map 按键排序）找到第一个缺失或为 null 的值。
+func (s *renderSession) missingPath(errMsg string) ([]interface{}, bool) {
+	if !strings.Contains(errMsg, "map has no entry for key") && !strings.Contains(errMsg, "nil pointer evaluating") {
+		return nil, false
+	}
+	locations := make([]string, 0, len(s.fieldRefs))
+	for location := range s.fieldRefs {
+		locations = append(locations, location)
+	}
+	sort.Strings(locations)
+	for _, location := range locations {
+		if !strings.Contains(errMsg, "template: "+location+": executing") {
+			continue
+		}
+		for _, node := range s.fieldRefs[location] {
+			if !node.rootedAt(s.root) {
+				continue
+			}
+			if path, ok := findMissing(s.data, nodePath(node)); ok {
+				return path, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// nodePath 返回从根节点（不含）到 node 的各级节点
+func nodePath(node *sampleNode) []*sampleNode {
+	var path []*sampleNode
+	for n := node; n.parent != nil; n = n.parent {
+		path = append([]*sampleNode{n}, path...)
+	}
+	return path
+}
+
+// findMissing 在 data 中沿 path 查找第一个缺失或为 null 的值，返回其具体路径
+func findMissing(data interface{}, path []*sampleNode) ([]interface{}, bool) {
+	if len(path) == 0 {
+		return nil, false
+	}
+	step := path[0]
+	if step.isElem {
+		switch container := data.(type) {
+		case []interface{}:
+			for i, item := range container {
+				if rest, ok := findMissing(item, path[1:]); ok {
+					return append([]interface{}{i}, rest...), true
+				}
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(container))
+			for k := range container {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if rest, ok := findMissing(container[k], path[1:]); ok {
+					return append([]interface{}{k}, rest...), true
+				}
+			}
+		}
+		return nil, false
+	}
+
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value := m[step.name]
+	if value == nil {
+		return []interface{}{step.name}, true
+	}
+	if rest, ok := findMissing(value, path[1:]); ok {
+		return append([]interface{}{step.name}, rest...), true
+	}
+	return nil, false
+}
+
+// setPath 将 value 写入 container 中 path 指定的位置并返回更新后的 container，
+// 缺失或为 null 的中间值会被创建为对象；列表只能按已有的下标写入
+func setPath(container interface{}, path []interface{}, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch c := container.(type) {
+	case nil:
+		child, err := setPath(nil, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{toString(path[0]): child}, nil
+	case map[string]interface{}:
+		key := toString(path[0])
+		child, err := setPath(c[key], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c[key] = child
+		return c, nil
+	case []interface{}:
+		index, err := toInt(path[0])
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || index >= len(c) {
+			return nil, fmt.Errorf("index %d out of range for list of length %d", index, len(c))
+		}
+		child, err := setPath(c[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c[index] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("cannot set %v on a value of type %T", path[0], container)
+}
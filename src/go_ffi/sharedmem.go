@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// sharedMemDir 是 Linux 上基于内存的 tmpfs 目录，其中的文件被映射时不经过磁盘
+const sharedMemDir = "/dev/shm"
+
+// sharedMemOutput 是 RenderToSharedMem 的输出
+type sharedMemOutput struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// sharedMemFiles 记录 RenderToSharedMem 创建且尚未释放的文件，
+// ReleaseSharedMem 只删除其中的文件，避免调用方借此删除任意文件
+var sharedMemFiles = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: map[string]bool{}}
+
+// renderToSharedMem 渲染模板并将输出写入共享内存中的新文件，返回文件路径和大小。
+// 存在 /dev/shm 时使用它，否则退回到系统的临时目录（此时文件可能位于磁盘上）。
+// 文件只有当前用户可以读写，调用方映射后需要通过 releaseSharedMem 删除。
+func renderToSharedMem(templateContent string, jsonData string, opts renderOptions) RenderResult {
+	result := renderGoTemplate(templateContent, jsonData, opts)
+	if result.Error != "" {
+		return result
+	}
+
+	dir := os.TempDir()
+	if info, err := os.Stat(sharedMemDir); err == nil && info.IsDir() {
+		dir = sharedMemDir
+	}
+	f, err := os.CreateTemp(dir, "gotpl-*.out")
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to create shared memory file: %v", err),
+		}
+	}
+	_, err = io.WriteString(f, result.Output)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to write shared memory file: %v", err),
+		}
+	}
+
+	sharedMemFiles.Lock()
+	sharedMemFiles.names[f.Name()] = true
+	sharedMemFiles.Unlock()
+
+	encoded, err := encodeJSON(sharedMemOutput{Name: f.Name(), Size: len(result.Output)})
+	if err != nil {
+		releaseSharedMem(f.Name())
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
+
+// releaseSharedMem 删除 renderToSharedMem 创建的文件，已经映射的内容在解除映射之前仍然有效
+func releaseSharedMem(name string) RenderResult {
+	sharedMemFiles.Lock()
+	defer sharedMemFiles.Unlock()
+	if !sharedMemFiles.names[name] {
+		return RenderResult{
+			Error: fmt.Sprintf("%q was not created by RenderToSharedMem or has already been released", name),
+		}
+	}
+	delete(sharedMemFiles.names, name)
+	if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to remove shared memory file: %v", err),
+		}
+	}
+	return RenderResult{}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// renderSharedMemFile 调用 renderToSharedMem 并解码输出，出错时测试失败
+func renderSharedMemFile(t *testing.T, templateContent string, jsonData string) sharedMemOutput {
+	t.Helper()
+	result := renderToSharedMem(templateContent, jsonData, renderOptions{})
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	var output sharedMemOutput
+	if err := json.Unmarshal([]byte(result.Output), &output); err != nil {
+		t.Fatal(err)
+	}
+	return output
+}
+
+// 渲染结果写入只有当前用户可读写的文件，释放后文件被删除
+func TestSharedMemRoundTrip(t *testing.T) {
+	output := renderSharedMemFile(t, `héllo {{ .name }}`, `{"name": "x"}`)
+	t.Cleanup(func() { releaseSharedMem(output.Name) })
+
+	if want := "héllo x"; output.Size != len(want) {
+		t.Errorf("got size %d, want %d", output.Size, len(want))
+	}
+	content, err := os.ReadFile(output.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "héllo x" {
+		t.Errorf("file contains %q, want %q", content, "héllo x")
+	}
+	info, err := os.Stat(output.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("file mode is %o, want 600", perm)
+	}
+	if dir := filepath.Dir(output.Name); dir != sharedMemDir && dir != filepath.Clean(os.TempDir()) {
+		t.Errorf("file created in %q, want %q or the temp directory", dir, sharedMemDir)
+	}
+
+	if result := releaseSharedMem(output.Name); result.Error != "" {
+		t.Fatalf("release failed: %s", result.Error)
+	}
+	if _, err := os.Stat(output.Name); !os.IsNotExist(err) {
+		t.Errorf("file still exists after release: %v", err)
+	}
+}
+
+// 每次渲染都创建新的文件
+func TestSharedMemDistinctFiles(t *testing.T) {
+	first := renderSharedMemFile(t, `a`, `{}`)
+	second := renderSharedMemFile(t, `b`, `{}`)
+	t.Cleanup(func() {
+		releaseSharedMem(first.Name)
+		releaseSharedMem(second.Name)
+	})
+	if first.Name == second.Name {
+		t.Errorf("both renders wrote to %q", first.Name)
+	}
+}
+
+// 重复释放、释放未知的名称或其他文件都会返回错误，不会删除任何文件
+func TestReleaseSharedMemErrors(t *testing.T) {
+	output := renderSharedMemFile(t, `x`, `{}`)
+	if result := releaseSharedMem(output.Name); result.Error != "" {
+		t.Fatalf("release failed: %s", result.Error)
+	}
+	if result := releaseSharedMem(output.Name); !strings.Contains(result.Error, "has already been released") {
+		t.Errorf("second release: got error %q", result.Error)
+	}
+
+	other := filepath.Join(t.TempDir(), "other.out")
+	if err := os.WriteFile(other, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{other, "", "gotpl-unknown.out"} {
+		if result := releaseSharedMem(name); !strings.Contains(result.Error, "was not created by RenderToSharedMem") {
+			t.Errorf("releasing %q: got error %q", name, result.Error)
+		}
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("unrelated file was removed: %v", err)
+	}
+}
+
+// 文件在释放前被外部删除时，释放仍然成功
+func TestReleaseSharedMemRemovedFile(t *testing.T) {
+	output := renderSharedMemFile(t, `x`, `{}`)
+	if err := os.Remove(output.Name); err != nil {
+		t.Fatal(err)
+	}
+	if result := releaseSharedMem(output.Name); result.Error != "" {
+		t.Errorf("got error %q, want none", result.Error)
+	}
+}
+
+// 渲染失败时不创建文件
+func TestRenderToSharedMemError(t *testing.T) {
+	result := renderToSharedMem(`{{ fail "boom" }}`, `{}`, renderOptions{})
+	if result.Error == "" || result.Output != "" {
+		t.Errorf("got %+v, want only an error", result)
+	}
+}
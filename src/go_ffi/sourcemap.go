@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// sourceActionFunc 是追加到输出动作管道末尾、用于记录当前节点的内部函数名
+const sourceActionFunc = "_gotpl_source_action"
+
+// sourceMapping 描述一段输出（[Start, End) 字节区间）来自哪个模板的哪个位置（行列均从 1 开始，列按字节计）。
+// 输出动作的位置为其开始分隔符（如 {{）所在处。
+type sourceMapping struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Template string `json:"template"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// sourceNode 是被记录的模板节点所在的位置
+type sourceNode struct {
+	template string
+	line     int
+	column   int
+}
+
+// sourceMapOutput 是 RenderWithSourceMap 的输出
+type sourceMapOutput struct {
+	Output   string          `json:"output"`
+	Mappings []sourceMapping `json:"mappings"`
+}
+
+// sourceText 是一个被记录的文本节点
+type sourceText struct {
+	node *parse.TextNode
+	id   int
+}
+
+// sourceMapRecorder 将每次写入输出归属到模板中的节点。
+// 文本节点的内容由 Go 模板原样写入，因此按写入切片的地址识别；
+// 输出动作则在管道末尾追加标记函数，在输出之前记录当前节点，
+// 这样管道中 include 等嵌套执行不会影响该动作自身输出的归属。
+type sourceMapRecorder struct {
+	source    string
+	leftDelim string
+	nodes     []sourceNode
+	texts     []sourceText
+	textIndex map[*byte]int
+	current   int
+	offset    int
+	mappings  []sourceMapping
+}
+
+// newSourceMapRecorder 创建记录器，leftDelim 为模板的开始分隔符，为空时使用 {{
+func newSourceMapRecorder(source string, leftDelim string) *sourceMapRecorder {
+	if leftDelim == "" {
+		leftDelim = "{{"
+	}
+	return &sourceMapRecorder{source: source, leftDelim: leftDelim, current: -1}
+}
+
+func (r *sourceMapRecorder) funcs() map[string]interface{} {
+	return map[string]interface{}{
+		// 参数和返回值为 reflect.Value，原样传递管道的结果，缺失值仍然输出为 <no value>
+		sourceActionFunc: func(id int, v reflect.Value) reflect.Value {
+			r.current = id
+			return v
+		},
+	}
+}
+
+// addNode 记录节点的位置并返回其编号
+func (r *sourceMapRecorder) addNode(template string, pos parse.Pos) int {
+	p := int(pos)
+	if p > len(r.source) {
+		p = len(r.source)
+	}
+	line := 1 + strings.Count(r.source[:p], "\n")
+	column := p - strings.LastIndex(r.source[:p], "\n")
+	r.nodes = append(r.nodes, sourceNode{template: template, line: line, column: column})
+	return len(r.nodes) - 1
+}
+
+// actionStart 返回 pos 处动作的开始分隔符的位置。Go 的解析器将动作的位置记为分隔符之后第一个记号，
+// 分隔符与记号之间可能有空白或 "- " 裁剪标记，因此向前查找最近的开始分隔符。
+func (r *sourceMapRecorder) actionStart(pos parse.Pos) parse.Pos {
+	p := int(pos)
+	if p > len(r.source) {
+		p = len(r.source)
+	}
+	if i := strings.LastIndex(r.source[:p], r.leftDelim); i >= 0 {
+		return parse.Pos(i)
+	}
+	return pos
+}
+
+// instrument 记录语法树中的文本节点并为输出动作追加标记，需要在首次执行之前调用
+func (r *sourceMapRecorder) instrument(name string, tree *parse.Tree) {
+	walkTree(tree.Root, func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			r.texts = append(r.texts, sourceText{node: n, id: r.addNode(name, n.Pos)})
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) == 0 {
+				id := r.addNode(name, r.actionStart(n.Pos))
+				appendPipeCommand(n.Pipe, parse.NewIdentifier(sourceActionFunc).SetPos(n.Pos), sourceNumber(id, n.Pos))
+			}
+		}
+	})
+}
+
+// sourceNumber 构造表示节点编号的数字常量
+func sourceNumber(id int, pos parse.Pos) *parse.NumberNode {
+	return &parse.NumberNode{
+		NodeType: parse.NodeNumber,
+		Pos:      pos,
+		IsInt:    true,
+		IsUint:   true,
+		IsFloat:  true,
+		Int64:    int64(id),
+		Uint64:   uint64(id),
+		Float64:  float64(id),
+		Text:     strconv.Itoa(id),
+	}
+}
+
+// textNode 返回 p 对应的文本节点编号。
+// html/template 在首次执行时才会转义并替换文本内容，因此索引在第一次写入时建立。
+func (r *sourceMapRecorder) textNode(p []byte) (int, bool) {
+	if r.textIndex == nil {
+		r.textIndex = make(map[*byte]int, len(r.texts))
+		for _, text := range r.texts {
+			if len(text.node.Text) > 0 {
+				r.textIndex[&text.node.Text[0]] = text.id
+			}
+		}
+	}
+	id, ok := r.textIndex[&p[0]]
+	return id, ok
+}
+
+// writer 包装输出，将每次写入归属到对应的节点，相邻且来自同一节点的区间会被合并
+func (r *sourceMapRecorder) writer(w io.Writer) io.Writer {
+	return sourceMapWriter{w: w, r: r}
+}
+
+type sourceMapWriter struct {
+	w io.Writer
+	r *sourceMapRecorder
+}
+
+func (sw sourceMapWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	if n > 0 {
+		sw.r.record(p, n)
+	}
+	return n, err
+}
+
+func (r *sourceMapRecorder) record(p []byte, n int) {
+	defer func() { r.offset += n }()
+
+	id, ok := r.textNode(p)
+	if !ok {
+		if r.current < 0 {
+			return
+		}
+		id = r.current
+	}
+	node := r.nodes[id]
+	if last := len(r.mappings) - 1; last >= 0 {
+		m := &r.mappings[last]
+		if m.End == r.offset && m.Template == node.template && m.Line == node.line && m.Column == node.column {
+			m.End += n
+			return
+		}
+	}
+	r.mappings = append(r.mappings, sourceMapping{
+		Start:    r.offset,
+		End:      r.offset + n,
+		Template: node.template,
+		Line:     node.line,
+		Column:   node.column,
+	})
+}
+
+// renderWithSourceMap 渲染模板，并记录输出的每个区间来自模板源码中的哪个位置。
+// 压缩、行号、换行符转换、输出过滤器和预览截断会改变输出的偏移，因此不能与源码映射同时使用。
+func renderWithSourceMap(templateContent string, jsonData string, opts renderOptions) RenderResult {
+	if opts.Minify != "" || opts.LineNumbers || (opts.LineEnding != "" && opts.LineEnding != "keep") || opts.PreviewBytes > 0 || len(opts.PostFilters) > 0 {
+		return RenderResult{
+			Error: "Source maps cannot be combined with the minify, lineNumbers, lineEnding, postFilters or previewBytes options",
+		}
+	}
+
+	data, err := decodeTemplateData(jsonData, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to unmarshal JSON data: %v", err),
+		}
+	}
+
+	tmpl, kind, err := parseGoTemplate(templateContent, opts)
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to parse %s template: %v", kind, err),
+		}
+	}
+
+	recorder := newSourceMapRecorder(templateContent, opts.LeftDelim)
+	addTemplateFuncs(tmpl, recorder.funcs())
+	for name, tree := range templateTrees(tmpl) {
+		recorder.instrument(name, tree)
+	}
+
+	buf, err := executeWithLimits(opts, func(w io.Writer) error {
+		return tmpl.Execute(recorder.writer(w), data)
+	})
+	if err != nil {
+		return executionError(kind, err)
+	}
+	if opts.FailOnEmptyOutput && buf.Len() == 0 {
+		return RenderResult{
+			Error: "rendered output was empty",
+		}
+	}
+
+	output := buf.String()
+	if opts.EmbedChecksum != "" {
+		// 校验和注释追加在末尾，不影响已记录的偏移，也不对应任何模板位置
+		output = embedChecksum(output, opts.EmbedChecksum, opts.LineEnding)
+	}
+	mappings := recorder.mappings
+	if mappings == nil {
+		mappings = []sourceMapping{}
+	}
+	encoded, err := encodeJSON(sourceMapOutput{Output: output, Mappings: mappings})
+	if err != nil {
+		return RenderResult{
+			Error: fmt.Sprintf("Failed to encode render results: %v", err),
+		}
+	}
+	return RenderResult{
+		Output: encoded,
+	}
+}
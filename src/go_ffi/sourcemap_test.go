@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// 输出动作映射到其开始分隔符所在的列，而不是分隔符之后第一个记号的位置
+func TestSourceMapColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		opts     renderOptions
+		want     []sourceMapping
+	}{
+		{
+			"default delimiters",
+			"ab{{ .x }}",
+			renderOptions{},
+			[]sourceMapping{
+				{Start: 0, End: 2, Template: rootTemplateName, Line: 1, Column: 1},
+				{Start: 2, End: 3, Template: rootTemplateName, Line: 1, Column: 3},
+			},
+		},
+		{
+			"leading whitespace inside action",
+			"{{    .x}}",
+			renderOptions{},
+			[]sourceMapping{{Start: 0, End: 1, Template: rootTemplateName, Line: 1, Column: 1}},
+		},
+		{
+			"trim marker",
+			"a\n  {{- .x -}}\n",
+			renderOptions{},
+			[]sourceMapping{
+				{Start: 0, End: 1, Template: rootTemplateName, Line: 1, Column: 1},
+				{Start: 1, End: 2, Template: rootTemplateName, Line: 2, Column: 3},
+			},
+		},
+		{
+			"custom delimiters",
+			"a\n  <%   .x %>",
+			renderOptions{LeftDelim: "<%", RightDelim: "%>"},
+			[]sourceMapping{
+				{Start: 0, End: 4, Template: rootTemplateName, Line: 1, Column: 1},
+				{Start: 4, End: 5, Template: rootTemplateName, Line: 2, Column: 3},
+			},
+		},
+		{
+			"inside define",
+			"{{ define \"d\" }}\n\t{{ .x }}{{ end }}{{ template \"d\" . }}",
+			renderOptions{},
+			[]sourceMapping{
+				{Start: 0, End: 2, Template: "d", Line: 1, Column: 17},
+				{Start: 2, End: 3, Template: "d", Line: 2, Column: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := renderWithSourceMap(tt.template, `{"x": "X"}`, tt.opts)
+			if result.Error != "" {
+				t.Fatalf("unexpected error: %s", result.Error)
+			}
+			var got sourceMapOutput
+			if err := json.Unmarshal([]byte(result.Output), &got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Mappings, tt.want) {
+				t.Errorf("got %+v, want %+v", got.Mappings, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// walkTree 深度优先遍历语法树，对每个节点调用 visit，包括管道、命令和参数中的节点
+func walkTree(node parse.Node, visit func(parse.Node)) {
+	if node == nil {
+		return
+	}
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		visit(n)
+		for _, child := range n.Nodes {
+			walkTree(child, visit)
+		}
+	case *parse.ActionNode:
+		visit(n)
+		walkTree(n.Pipe, visit)
+	case *parse.IfNode:
+		visit(n)
+		walkBranch(&n.BranchNode, visit)
+	case *parse.RangeNode:
+		visit(n)
+		walkBranch(&n.BranchNode, visit)
+	case *parse.WithNode:
+		visit(n)
+		walkBranch(&n.BranchNode, visit)
+	case *parse.TemplateNode:
+		visit(n)
+		walkTree(n.Pipe, visit)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		visit(n)
+		for _, cmd := range n.Cmds {
+			walkTree(cmd, visit)
+		}
+	case *parse.CommandNode:
+		visit(n)
+		for _, arg := range n.Args {
+			walkTree(arg, visit)
+		}
+	case *parse.ChainNode:
+		visit(n)
+		walkTree(n.Node, visit)
+	default:
+		visit(n)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, visit func(parse.Node)) {
+	walkTree(n.Pipe, visit)
+	walkTree(n.List, visit)
+	walkTree(n.ElseList, visit)
+}
+
+// usedFuncs 返回语法树中调用的所有函数名（包括 Go 模板的内置函数），按名称排序且不重复
+func usedFuncs(trees map[string]*parse.Tree) []string {
+	seen := map[string]bool{}
+	for _, tree := range trees {
+		walkTree(tree.Root, func(node parse.Node) {
+			if ident, ok := node.(*parse.IdentifierNode); ok {
+				seen[ident.Ident] = true
+			}
+		})
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateLineFunc 是返回当前模板行号的函数名
+const templateLineFunc = "templateLine"
+
+// templateLine 只用于让解析器接受 templateLine 这个名称。
+// Go 模板执行时无法得知当前位置，因此解析后 resolveTemplateLines 会把每次调用替换为行号常量，
+// 正常情况下本函数不会被执行。
+func templateLine() (int, error) {
+	return 0, fmt.Errorf("%s was not resolved at parse time", templateLineFunc)
+}
+
+// resolveTemplateLines 将语法树中的 templateLine 调用替换为其在 source 中所在的行号（从 1 开始）。
+// define 块中节点的位置同样是相对于整个源码的偏移，因此行号指向原始模板中的行。
+func resolveTemplateLines(root *parse.ListNode, source string) {
+	walkTree(root, func(node parse.Node) {
+		cmd, ok := node.(*parse.CommandNode)
+		if !ok {
+			return
+		}
+		for i, arg := range cmd.Args {
+			ident, ok := arg.(*parse.IdentifierNode)
+			if !ok || ident.Ident != templateLineFunc || int(ident.Pos) > len(source) {
+				continue
+			}
+			// 带参数的调用保持原样，由执行时的参数个数检查报错
+			if i == 0 && len(cmd.Args) > 1 {
+				continue
+			}
+			line := 1 + strings.Count(source[:ident.Pos], "\n")
+			cmd.Args[i] = &parse.NumberNode{
+				NodeType: parse.NodeNumber,
+				Pos:      ident.Pos,
+				IsInt:    true,
+				IsUint:   true,
+				IsFloat:  true,
+				Int64:    int64(line),
+				Uint64:   uint64(line),
+				Float64:  float64(line),
+				Text:     strconv.Itoa(line),
+			}
+		}
+	})
+}
+
+// rewriteTree 在执行前改写解析得到的语法树：解析 templateLine 调用，并按选项追加缺失值占位符
+func rewriteTree(tree *parse.Tree, source string, opts renderOptions) {
+	if tree == nil || tree.Root == nil {
+		return
+	}
+	resolveTemplateLines(tree.Root, source)
+	if opts.MissingPlaceholder != nil {
+		guardMissingValues(tree.Root)
+	}
+}
+
+// appendPipeCommand 在管道末尾追加一个命令。html/template 要求预定义的转义函数 html 和 urlquery
+// 必须位于管道末尾，因此遇到它们时命令会插入到其之前。
+func appendPipeCommand(pipe *parse.PipeNode, args ...parse.Node) {
+	cmd := &parse.CommandNode{NodeType: parse.NodeCommand, Pos: pipe.Pos, Args: args}
+	i := len(pipe.Cmds)
+	for i > 0 && isPredefinedEscaper(pipe.Cmds[i-1]) {
+		i--
+	}
+	pipe.Cmds = append(pipe.Cmds, nil)
+	copy(pipe.Cmds[i+1:], pipe.Cmds[i:])
+	pipe.Cmds[i] = cmd
+}
+
+// isPredefinedEscaper 判断命令是否为 html 或 urlquery
+func isPredefinedEscaper(cmd *parse.CommandNode) bool {
+	if len(cmd.Args) == 0 {
+		return false
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	return ok && (ident.Ident == "html" || ident.Ident == "urlquery")
+}
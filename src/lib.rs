@@ -1,9 +1,14 @@
-use serde::Serialize;
+use serde::{Deserialize, Serialize};
+use std::any::Any;
+use std::collections::BTreeMap;
 use std::error::Error;
 use std::ffi::{CStr, CString, NulError};
 use std::fmt::{self, Display, Formatter};
 use std::marker::PhantomData;
-// use std::os::raw::c_char; // 添加这个导入
+use std::os::raw::{c_char, c_int, c_void};
+use std::panic::{self, AssertUnwindSafe};
+use std::sync::{Arc, RwLock};
+use std::time::Duration;
 
 #[cfg(not(docsrs))]
 mod goffi {
@@ -17,7 +22,7 @@ mod goffi {
 
 #[cfg(docsrs)]
 mod goffi {
-    use std::os::raw::c_char;
+    use std::os::raw::{c_char, c_int, c_longlong, c_void};
 
     #[repr(C)]
     pub struct RenderResult {
@@ -25,14 +30,94 @@ mod goffi {
         pub error: *mut c_char,  // 改为 c_char
     }
 
+    #[repr(C)]
+    pub struct BytesResult {
+        pub data: *mut c_char,
+        pub length: usize,
+        pub error: *mut c_char,
+    }
+
+    #[repr(C)]
+    pub struct LinesResult {
+        pub lines: *mut *mut c_char,
+        pub count: c_int,
+        pub error: *mut c_char,
+    }
+
+    pub type batch_write_callback_t = Option<
+        unsafe extern "C" fn(
+            user_data: *mut c_void,
+            index: c_int,
+            output: *const c_char,
+            error: *const c_char,
+        ) -> bool,
+    >;
+
+    pub type log_callback_t = Option<unsafe extern "C" fn(level: c_int, message: *const c_char)>;
+
     extern "C" {
-        pub fn RenderTemplate(
-            template_content: *mut c_char, // 改为 c_char
-            json_data: *mut c_char,        // 改为 c_char
+        pub fn SetLogCallback(cb: log_callback_t);
+        pub fn RenderWithOptions(
+            template_content: *mut c_char,
+            json_data: *mut c_char,
+            options_json: *mut c_char,
+        ) -> RenderResult;
+        pub fn RenderWithFallback(
+            main_source: *mut c_char,
+            fallback_source: *mut c_char,
+            json_data: *mut c_char,
             escape_html: bool,
             use_missing_key_zero: bool,
+            fail_on_empty_output: bool,
+            line_numbers: bool,
+            root_key: *mut c_char,
+            minify_type: *mut c_char,
+        ) -> RenderResult;
+        pub fn RenderAll(
+            template_content: *mut c_char,
+            json_data: *mut c_char,
+            options_json: *mut c_char,
         ) -> RenderResult;
+        pub fn RenderMatrix(
+            template_content: *mut c_char,
+            matrix_json: *mut c_char,
+            options_json: *mut c_char,
+        ) -> RenderResult;
+        pub fn BeginRenderSession(
+            template_content: *mut c_char,
+            json_data: *mut c_char,
+            options_json: *mut c_char,
+        ) -> RenderResult;
+        pub fn ProvideKey(
+            session: c_longlong,
+            path_json: *mut c_char,
+            value_json: *mut c_char,
+        ) -> RenderResult;
+        pub fn EndRenderSession(session: c_longlong);
+        pub fn RenderBatch(
+            template_content: *mut c_char,
+            data_json_array: *mut c_char,
+            options_json: *mut c_char,
+            write_callback: batch_write_callback_t,
+            user_data: *mut c_void,
+        ) -> RenderResult;
+        pub fn CheckTemplateSet(
+            names_json: *mut c_char,
+            sources_json: *mut c_char,
+            allowed_funcs_json: *mut c_char,
+        ) -> RenderResult;
+        pub fn RenderBytes(
+            template_content: *mut c_char,
+            json_data: *mut c_char,
+            options_json: *mut c_char,
+        ) -> BytesResult;
+        pub fn RenderLines(
+            template_content: *mut c_char,
+            json_data: *mut c_char,
+            options_json: *mut c_char,
+        ) -> LinesResult;
         pub fn FreeResultString(s: *mut c_char); // 改为 c_char
+        pub fn FreeStringArray(arr: *mut *mut c_char, count: c_int);
     }
 }
 
@@ -41,6 +126,8 @@ pub enum RenderError {
     InvalidCString(NulError),
     JsonSerialization(serde_json::Error),
     GoExecution(String),
+    /// The template aborted itself with `fail` or `failIf`; holds the author's message.
+    TemplateFailure(String),
 }
 
 impl Display for RenderError {
@@ -53,6 +140,7 @@ impl Display for RenderError {
                 write!(f, "Failed to serialize data to JSON: {}", e)
             }
             RenderError::GoExecution(e) => write!(f, "Go template execution error: {}", e),
+            RenderError::TemplateFailure(e) => write!(f, "Template failure: {}", e),
         }
     }
 }
@@ -62,7 +150,7 @@ impl Error for RenderError {
         match self {
             RenderError::InvalidCString(e) => Some(e),
             RenderError::JsonSerialization(e) => Some(e),
-            RenderError::GoExecution(_) => None,
+            RenderError::GoExecution(_) | RenderError::TemplateFailure(_) => None,
         }
     }
 }
@@ -79,6 +167,19 @@ impl From<serde_json::Error> for RenderError {
     }
 }
 
+/// Prefix Go puts in front of errors raised by the `fail` and `failIf` helpers.
+const TEMPLATE_FAILURE_PREFIX: &str = "Template failure: ";
+
+impl RenderError {
+    /// Converts an error message returned by Go into a `RenderError`.
+    fn from_go(error: String) -> Self {
+        match error.strip_prefix(TEMPLATE_FAILURE_PREFIX) {
+            Some(msg) => RenderError::TemplateFailure(msg.to_string()),
+            None => RenderError::GoExecution(error),
+        }
+    }
+}
+
 struct OwnedGoResult(goffi::RenderResult);
 
 impl Drop for OwnedGoResult {
@@ -90,12 +191,421 @@ impl Drop for OwnedGoResult {
     }
 }
 
+impl OwnedGoResult {
+    /// Returns the output, or the error Go reported.
+    fn into_result(self) -> Result<String, RenderError> {
+        let (output, error) = self.into_parts();
+        if !error.is_empty() {
+            Err(RenderError::from_go(error))
+        } else {
+            Ok(output)
+        }
+    }
+
+    /// Returns both the output and the error string, which is empty on success.
+    fn into_parts(self) -> (String, String) {
+        unsafe {
+            (
+                CStr::from_ptr(self.0.output).to_string_lossy().into_owned(),
+                CStr::from_ptr(self.0.error).to_string_lossy().into_owned(),
+            )
+        }
+    }
+}
+
+/// Output of [`TemplateRenderer::render_with_fallback`].
+#[derive(Debug)]
+pub struct FallbackOutput {
+    pub output: String,
+    /// The main template's error when `output` came from the fallback template.
+    pub primary_error: Option<RenderError>,
+}
+
+/// A single unit's result inside the JSON output of the multi-render exports.
+#[derive(Deserialize)]
+struct GoUnitOutput {
+    #[serde(default)]
+    output: String,
+    #[serde(default)]
+    error: String,
+}
+
+impl GoUnitOutput {
+    fn into_result(self) -> Result<String, RenderError> {
+        if !self.error.is_empty() {
+            Err(RenderError::from_go(self.error))
+        } else {
+            Ok(self.output)
+        }
+    }
+}
+
+/// One successfully rendered unit listed by the `*_with_manifest` methods.
+#[derive(Debug, Clone, PartialEq, Eq, Deserialize)]
+pub struct ManifestEntry {
+    /// The template name for `render_all`, or the index of the combination or element
+    /// for `render_matrix` and `render_batch`.
+    pub name: String,
+    /// Length of the output in bytes.
+    pub length: usize,
+    /// Hex-encoded SHA-256 of the output.
+    pub sha256: String,
+}
+
+/// The JSON output of a multi-render export when the `manifest` option is set.
+#[derive(Deserialize)]
+struct GoManifestOutput<T> {
+    #[serde(alias = "outputs")]
+    results: T,
+    manifest: Vec<ManifestEntry>,
+}
+
+/// One parameter combination rendered by [`TemplateRenderer::render_matrix`].
+#[derive(Debug)]
+pub struct MatrixOutput {
+    /// The value of each matrix variable in this combination.
+    pub params: serde_json::Map<String, serde_json::Value>,
+    /// The rendered output, or the error for this combination alone.
+    pub result: Result<String, RenderError>,
+}
+
+#[derive(Deserialize)]
+struct GoMatrixOutput {
+    params: serde_json::Map<String, serde_json::Value>,
+    #[serde(flatten)]
+    output: GoUnitOutput,
+}
+
+fn named_results(
+    outputs: BTreeMap<String, GoUnitOutput>,
+) -> BTreeMap<String, Result<String, RenderError>> {
+    outputs
+        .into_iter()
+        .map(|(name, output)| (name, output.into_result()))
+        .collect()
+}
+
+fn matrix_results(outputs: Vec<GoMatrixOutput>) -> Vec<MatrixOutput> {
+    outputs
+        .into_iter()
+        .map(|output| MatrixOutput {
+            params: output.params,
+            result: output.output.into_result(),
+        })
+        .collect()
+}
+
+/// The state of an interactive render started with [`TemplateRenderer::begin_session`].
+#[derive(Debug)]
+pub enum SessionState {
+    /// Rendering succeeded; holds the output. The session has ended.
+    Done(String),
+    /// Rendering stopped at a missing key; supply it with [`RenderSession::provide`].
+    NeedsKey(RenderSession),
+}
+
+/// An unfinished interactive render waiting for a missing key.
+///
+/// Dropping it abandons the render and frees its state in Go.
+#[derive(Debug)]
+pub struct RenderSession {
+    id: i64,
+    missing_path: Vec<serde_json::Value>,
+}
+
+#[derive(Deserialize)]
+#[serde(rename_all = "camelCase")]
+struct GoSessionOutput {
+    session: i64,
+    done: bool,
+    output: String,
+    #[serde(default)]
+    missing_path: Vec<serde_json::Value>,
+}
+
+impl SessionState {
+    /// Converts the output of a session export. `session` is the handle that made the
+    /// call, if any; it is reused while keys are still missing so that dropping it does
+    /// not end the session.
+    fn from_go(result: OwnedGoResult, session: Option<RenderSession>) -> Result<Self, RenderError> {
+        let output: GoSessionOutput = serde_json::from_str(&result.into_result()?)?;
+        if output.done {
+            return Ok(SessionState::Done(output.output));
+        }
+        let mut session = session.unwrap_or_else(|| RenderSession {
+            id: output.session,
+            missing_path: Vec::new(),
+        });
+        session.missing_path = output.missing_path;
+        Ok(SessionState::NeedsKey(session))
+    }
+}
+
+impl RenderSession {
+    /// Path of the first missing key: strings are map keys, numbers are list indexes.
+    pub fn missing_path(&self) -> &[serde_json::Value] {
+        &self.missing_path
+    }
+
+    /// Sets the missing key to `value` and renders again.
+    ///
+    /// Returns the next missing key, the output once nothing is missing, or any other
+    /// render error, which also ends the session.
+    pub fn provide<V: Serialize>(self, value: &V) -> Result<SessionState, RenderError> {
+        let c_path = CString::new(serde_json::to_string(&self.missing_path)?)?;
+        let c_value = CString::new(serde_json::to_string(value)?)?;
+
+        let result = unsafe {
+            OwnedGoResult(goffi::ProvideKey(
+                self.id as _,
+                c_path.as_ptr() as *mut c_char,
+                c_value.as_ptr() as *mut c_char,
+            ))
+        };
+        SessionState::from_go(result, Some(self))
+    }
+}
+
+impl Drop for RenderSession {
+    fn drop(&mut self) {
+        // 会话在 Go 中结束后再次结束不会有任何效果，因此这里总是可以调用
+        unsafe { goffi::EndRenderSession(self.id as _) }
+    }
+}
+
+/// Totals returned by [`TemplateRenderer::render_batch_each`].
+#[derive(Debug, Deserialize)]
+pub struct BatchSummary {
+    /// Number of elements rendered, including failed ones.
+    pub count: usize,
+    /// Number of elements whose render failed.
+    pub failed: usize,
+    /// Whether the callback returned `false` before the end of the array.
+    pub stopped: bool,
+}
+
+/// State shared with [`batch_write_trampoline`] through the callback's user data.
+struct BatchCallback<F> {
+    on_result: F,
+    /// A panic raised by `on_result`, resumed once Go returns so it never unwinds through Go.
+    panic: Option<Box<dyn Any + Send>>,
+}
+
+unsafe extern "C" fn batch_write_trampoline<F>(
+    user_data: *mut c_void,
+    index: c_int,
+    output: *const c_char,
+    error: *const c_char,
+) -> bool
+where
+    F: FnMut(usize, Result<&str, RenderError>) -> bool,
+{
+    let state = &mut *(user_data as *mut BatchCallback<F>);
+    let output = CStr::from_ptr(output).to_string_lossy();
+    let error = CStr::from_ptr(error).to_string_lossy();
+    let result = if error.is_empty() {
+        Ok(output.as_ref())
+    } else {
+        Err(RenderError::from_go(error.into_owned()))
+    };
+    match panic::catch_unwind(AssertUnwindSafe(|| {
+        (state.on_result)(index as usize, result)
+    })) {
+        Ok(keep_going) => keep_going,
+        Err(payload) => {
+            state.panic = Some(payload);
+            false
+        }
+    }
+}
+
+struct OwnedGoBytes(goffi::BytesResult);
+
+impl Drop for OwnedGoBytes {
+    fn drop(&mut self) {
+        unsafe {
+            goffi::FreeResultString(self.0.data);
+            goffi::FreeResultString(self.0.error);
+        }
+    }
+}
+
+struct OwnedGoLines(goffi::LinesResult);
+
+impl Drop for OwnedGoLines {
+    fn drop(&mut self) {
+        unsafe {
+            goffi::FreeStringArray(self.0.lines, self.0.count);
+            goffi::FreeResultString(self.0.error);
+        }
+    }
+}
+
+/// Severity of a message passed to the callback registered with [`set_log_callback`].
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum LogLevel {
+    Debug,
+    Info,
+    Warn,
+    Error,
+}
+
+type LogCallback = Arc<dyn Fn(LogLevel, &str) + Send + Sync>;
+
+static LOG_CALLBACK: RwLock<Option<LogCallback>> = RwLock::new(None);
+
+unsafe extern "C" fn log_trampoline(level: c_int, message: *const c_char) {
+    let callback = LOG_CALLBACK
+        .read()
+        .unwrap_or_else(|poisoned| poisoned.into_inner())
+        .clone();
+    if let Some(callback) = callback {
+        let level = match level {
+            0 => LogLevel::Debug,
+            1 => LogLevel::Info,
+            2 => LogLevel::Warn,
+            _ => LogLevel::Error,
+        };
+        let message = CStr::from_ptr(message).to_string_lossy();
+        // panic 不能穿过 Go 的栈帧展开，只能在这里丢弃
+        let _ = panic::catch_unwind(AssertUnwindSafe(|| callback(level, &message)));
+    }
+}
+
+/// Routes the diagnostics Go emits while parsing and rendering to `callback`, replacing
+/// any callback registered before.
+///
+/// The callback is process-wide and may be called concurrently from any thread. A panic
+/// inside it is caught and discarded, since it cannot unwind through Go.
+pub fn set_log_callback<F>(callback: F)
+where
+    F: Fn(LogLevel, &str) + Send + Sync + 'static,
+{
+    *LOG_CALLBACK
+        .write()
+        .unwrap_or_else(|poisoned| poisoned.into_inner()) = Some(Arc::new(callback));
+    unsafe { goffi::SetLogCallback(Some(log_trampoline)) };
+}
+
+/// Unregisters the callback set with [`set_log_callback`]; Go stops formatting log messages.
+pub fn clear_log_callback() {
+    unsafe { goffi::SetLogCallback(None) };
+    *LOG_CALLBACK
+        .write()
+        .unwrap_or_else(|poisoned| poisoned.into_inner()) = None;
+}
+
+/// Result of [`check_template_set`].
+#[derive(Debug, Deserialize)]
+pub struct TemplateSetReport {
+    /// Whether no file has a problem; `recursive_templates` does not count.
+    pub ok: bool,
+    pub files: Vec<TemplateFileReport>,
+}
+
+/// Problems found in one file checked by [`check_template_set`].
+#[derive(Debug, Deserialize)]
+#[serde(rename_all = "camelCase")]
+pub struct TemplateFileReport {
+    pub name: String,
+    /// Set when the file does not parse; the other lists are empty then.
+    #[serde(default)]
+    pub parse_error: Option<String>,
+    pub unknown_funcs: Vec<String>,
+    /// Functions that must be enabled through an option, such as `renderString`.
+    pub disabled_funcs: Vec<String>,
+    pub disallowed_funcs: Vec<String>,
+    pub unresolved_templates: Vec<String>,
+    /// Templates that can reach themselves through `template`; informational only.
+    pub recursive_templates: Vec<String>,
+}
+
+/// Checks a set of `(name, source)` template files without executing them, reporting
+/// parse errors, unknown or disallowed functions and unresolved `template` references.
+///
+/// When `allowed_funcs` is `None` functions are only checked for being registered.
+/// The error covers invalid input only; problems in the files are in the report.
+pub fn check_template_set(
+    files: &[(&str, &str)],
+    allowed_funcs: Option<&[&str]>,
+) -> Result<TemplateSetReport, RenderError> {
+    let names: Vec<&str> = files.iter().map(|(name, _)| *name).collect();
+    let sources: Vec<&str> = files.iter().map(|(_, source)| *source).collect();
+    let c_names = CString::new(serde_json::to_string(&names)?)?;
+    let c_sources = CString::new(serde_json::to_string(&sources)?)?;
+    let c_allowed = CString::new(match allowed_funcs {
+        Some(allowed) => serde_json::to_string(allowed)?,
+        None => String::new(),
+    })?;
+
+    let output = unsafe {
+        OwnedGoResult(goffi::CheckTemplateSet(
+            c_names.as_ptr() as *mut c_char,
+            c_sources.as_ptr() as *mut c_char,
+            c_allowed.as_ptr() as *mut c_char,
+        ))
+    }
+    .into_result()?;
+    Ok(serde_json::from_str(&output)?)
+}
+
+/// Render options passed to Go's `RenderWithOptions` as a JSON object.
+#[derive(Serialize)]
+#[serde(rename_all = "camelCase")]
+struct GoRenderOptions<'a> {
+    escape_html: bool,
+    missing_key: &'a str,
+    left_delim: &'a str,
+    right_delim: &'a str,
+    timeout_ms: u64,
+    max_output_bytes: u64,
+    preview_bytes: usize,
+    expected_size: usize,
+    fail_on_empty_output: bool,
+    line_numbers: bool,
+    root_key: &'a str,
+    minify: &'a str,
+    reference_time: &'a str,
+    preprocess: &'a [&'a str],
+    missing_placeholder: Option<&'a str>,
+    output_encoding: &'a str,
+    replace_unencodable: bool,
+    line_ending: &'a str,
+    strict_funcs: bool,
+    post_filters: &'a [&'a str],
+    embed_checksum: &'a str,
+    allow_render_string: bool,
+    constants: Option<&'a serde_json::Value>,
+    manifest: bool,
+}
+
 /// Go Template Renderer
 pub struct TemplateRenderer<'a, T: Serialize> {
     template_content: &'a str,
     data: &'a T,
     escape_html: bool,
     use_missing_key_zero: bool,
+    fail_on_empty_output: bool,
+    line_numbers: bool,
+    root_key: &'a str,
+    minify_type: &'a str,
+    left_delim: &'a str,
+    right_delim: &'a str,
+    timeout: Option<Duration>,
+    max_output_bytes: Option<usize>,
+    preview_bytes: usize,
+    expected_size: usize,
+    reference_time: &'a str,
+    preprocess: &'a [&'a str],
+    missing_placeholder: Option<&'a str>,
+    output_encoding: &'a str,
+    replace_unencodable: bool,
+    line_ending: &'a str,
+    strict_funcs: bool,
+    post_filters: &'a [&'a str],
+    embed_checksum: &'a str,
+    allow_render_string: bool,
+    constants: Option<&'a serde_json::Value>,
     _marker: PhantomData<&'a T>,
 }
 
@@ -111,6 +621,27 @@ impl<'a, T: Serialize> TemplateRenderer<'a, T> {
             data,
             escape_html: false,
             use_missing_key_zero: false,
+            fail_on_empty_output: false,
+            line_numbers: false,
+            root_key: "",
+            minify_type: "",
+            left_delim: "",
+            right_delim: "",
+            timeout: None,
+            max_output_bytes: None,
+            preview_bytes: 0,
+            expected_size: 0,
+            reference_time: "",
+            preprocess: &[],
+            missing_placeholder: None,
+            output_encoding: "",
+            replace_unencodable: false,
+            line_ending: "",
+            strict_funcs: false,
+            post_filters: &[],
+            embed_checksum: "",
+            allow_render_string: false,
+            constants: None,
             _marker: PhantomData,
         }
     }
@@ -131,50 +662,544 @@ impl<'a, T: Serialize> TemplateRenderer<'a, T> {
         self
     }
 
+    /// Sets whether rendering that produces no output should be treated as an error.
+    ///
+    /// Defaults to `false`.
+    pub fn fail_on_empty_output(mut self, fail: bool) -> Self {
+        self.fail_on_empty_output = fail;
+        self
+    }
+
+    /// Sets whether to prefix each output line with its line number.
+    ///
+    /// Intended for debugging only, as it changes the rendered output. Defaults to `false`.
+    pub fn line_numbers(mut self, enable: bool) -> Self {
+        self.line_numbers = enable;
+        self
+    }
+
+    /// Wraps the data under the given root key before rendering.
+    ///
+    /// For example, with `root_key("Values")` the data is accessed as `.Values.name`,
+    /// which allows reusing Helm-style templates. An empty key (the default) leaves
+    /// the data unchanged.
+    pub fn root_key(mut self, key: &'a str) -> Self {
+        self.root_key = key;
+        self
+    }
+
+    /// Minifies the rendered output as the given content type.
+    ///
+    /// Supported types are `"html"`, `"css"`, `"js"` and `"json"`. An empty type
+    /// (the default) disables minification.
+    pub fn minify(mut self, content_type: &'a str) -> Self {
+        self.minify_type = content_type;
+        self
+    }
+
+    /// Sets custom action delimiters, e.g. `("[[", "]]")`.
+    ///
+    /// Empty strings keep Go's default `{{` and `}}`.
+    pub fn delimiters(mut self, left: &'a str, right: &'a str) -> Self {
+        self.left_delim = left;
+        self.right_delim = right;
+        self
+    }
+
+    /// Aborts rendering with an error if template execution takes longer than `timeout`.
+    ///
+    /// Defaults to no timeout.
+    pub fn timeout(mut self, timeout: Duration) -> Self {
+        self.timeout = Some(timeout);
+        self
+    }
+
+    /// Aborts rendering with an error once the output grows beyond `max` bytes.
+    ///
+    /// Defaults to no limit.
+    pub fn max_output_bytes(mut self, max: usize) -> Self {
+        self.max_output_bytes = Some(max);
+        self
+    }
+
+    /// Stops rendering once the output grows beyond `bytes` bytes and returns what was
+    /// rendered so far, followed by `... (truncated)`, e.g. for a live preview.
+    ///
+    /// The cut never splits a UTF-8 character. Unlike
+    /// [`max_output_bytes`](Self::max_output_bytes), this is not an error.
+    /// Defaults to `0` (no truncation).
+    pub fn preview_bytes(mut self, bytes: usize) -> Self {
+        self.preview_bytes = bytes;
+        self
+    }
+
+    /// Hints the expected output size in bytes so the output buffer can be allocated up front.
+    ///
+    /// Only affects performance. Defaults to `0` (no preallocation).
+    pub fn expected_size(mut self, size: usize) -> Self {
+        self.expected_size = size;
+        self
+    }
+
+    /// Fixes the current time used by time helpers such as `timeAgo`, as an RFC3339 timestamp.
+    ///
+    /// Makes time-relative output reproducible in tests. Defaults to the real current time.
+    pub fn reference_time(mut self, time: &'a str) -> Self {
+        self.reference_time = time;
+        self
+    }
+
+    /// Applies named data transforms, in order, before rendering.
+    ///
+    /// Supported transforms are `"lowercaseKeys"`, `"trimStrings"` and `"removeNulls"`;
+    /// each recurses into nested maps and lists. Unknown names make rendering fail.
+    pub fn preprocess(mut self, transforms: &'a [&'a str]) -> Self {
+        self.preprocess = transforms;
+        self
+    }
+
+    /// Renders missing keys and `null` values as `placeholder` instead of Go's `<no value>`.
+    ///
+    /// This covers field lookups and variables as well as function results such as
+    /// `{{index .m "missing"}}`. An empty placeholder renders nothing. Literal `<no value>`
+    /// text in the data or template is not affected.
+    pub fn missing_placeholder(mut self, placeholder: &'a str) -> Self {
+        self.missing_placeholder = Some(placeholder);
+        self
+    }
+
+    /// Transcodes the output to the named charset, e.g. `"ISO-8859-1"`, `"latin1"` or `"GBK"`.
+    ///
+    /// Only supported by [`render_bytes`](Self::render_bytes); [`render`](Self::render)
+    /// returns an error when an encoding is set.
+    pub fn output_encoding(mut self, charset: &'a str) -> Self {
+        self.output_encoding = charset;
+        self
+    }
+
+    /// Sets whether characters the output encoding cannot represent are replaced
+    /// (usually with `0x1A`) instead of failing the render.
+    ///
+    /// Defaults to `false`.
+    pub fn replace_unencodable(mut self, replace: bool) -> Self {
+        self.replace_unencodable = replace;
+        self
+    }
+
+    /// Normalizes every line ending in the output to `"lf"` or `"crlf"`.
+    ///
+    /// Mixed input is handled, and CRLF stays CRLF rather than becoming CRCRLF.
+    /// An empty string or `"keep"` (the default) leaves line endings unchanged.
+    pub fn line_ending(mut self, ending: &'a str) -> Self {
+        self.line_ending = ending;
+        self
+    }
+
+    /// Sets whether to check every function the template calls before parsing it,
+    /// reporting all unknown function names in a single error.
+    ///
+    /// Defaults to `false`, in which case only the first unknown function is reported.
+    pub fn strict_funcs(mut self, strict: bool) -> Self {
+        self.strict_funcs = strict;
+        self
+    }
+
+    /// Applies named filters, in the given order, to the rendered output.
+    ///
+    /// Supported filters are `"trim"`, `"trimTrailingSpace"`, `"lf"`, `"crlf"`,
+    /// `"ensureTrailingNewline"`, `"minifyHtml"`, `"minifyCss"`, `"minifyJs"` and
+    /// `"minifyJson"`. They run after [`minify`](Self::minify) and
+    /// [`line_ending`](Self::line_ending); unknown names make rendering fail.
+    pub fn post_filters(mut self, filters: &'a [&'a str]) -> Self {
+        self.post_filters = filters;
+        self
+    }
+
+    /// Appends a comment line recording the SHA-256 of the output, e.g.
+    /// `# gotpl-checksum: sha256:<hex>`, so consumers can detect later edits with
+    /// Go's `VerifyChecksum`.
+    ///
+    /// `comment_prefix` is `"#"`, `"//"` or `"<!--"` (closed with ` -->`). The checksum covers
+    /// everything above the comment, after all other post-processing. Cannot be combined with
+    /// [`preview_bytes`](Self::preview_bytes) or [`output_encoding`](Self::output_encoding).
+    pub fn embed_checksum(mut self, comment_prefix: &'a str) -> Self {
+        self.embed_checksum = comment_prefix;
+        self
+    }
+
+    /// Sets whether the `renderString` helper is available.
+    ///
+    /// `renderString` parses and executes template text taken from the data, so it is
+    /// disabled by default; only enable it when the data is trusted.
+    pub fn allow_render_string(mut self, allow: bool) -> Self {
+        self.allow_render_string = allow;
+        self
+    }
+
+    /// Passes named constants that templates read with `const "NAME"`.
+    ///
+    /// `constants` must be a JSON object. Constants are kept apart from the data, so they
+    /// never show up when ranging over it; reading an undefined name fails the render.
+    pub fn constants(mut self, constants: &'a serde_json::Value) -> Self {
+        self.constants = Some(constants);
+        self
+    }
+
+    /// Collects the builder settings into the options object sent to Go.
+    fn go_options(&self) -> GoRenderOptions<'a> {
+        GoRenderOptions {
+            escape_html: self.escape_html,
+            missing_key: if self.use_missing_key_zero {
+                "zero"
+            } else {
+                "default"
+            },
+            left_delim: self.left_delim,
+            right_delim: self.right_delim,
+            timeout_ms: self.timeout.map_or(0, |t| t.as_millis().max(1) as u64),
+            max_output_bytes: self.max_output_bytes.map_or(0, |max| max as u64),
+            preview_bytes: self.preview_bytes,
+            expected_size: self.expected_size,
+            fail_on_empty_output: self.fail_on_empty_output,
+            line_numbers: self.line_numbers,
+            root_key: self.root_key,
+            minify: self.minify_type,
+            reference_time: self.reference_time,
+            preprocess: self.preprocess,
+            missing_placeholder: self.missing_placeholder,
+            output_encoding: self.output_encoding,
+            replace_unencodable: self.replace_unencodable,
+            line_ending: self.line_ending,
+            strict_funcs: self.strict_funcs,
+            post_filters: self.post_filters,
+            embed_checksum: self.embed_checksum,
+            allow_render_string: self.allow_render_string,
+            constants: self.constants,
+            manifest: false,
+        }
+    }
+
     /// Executes the template rendering.
     ///
     /// # Returns
     /// Ok(String) if rendering was successful, Err(RenderError) otherwise.
     pub fn render(self) -> Result<String, RenderError> {
-        // Prepare inputs
         let c_template = CString::new(self.template_content)?;
-        let json_data_string = serde_json::to_string(self.data)?;
-        let c_json_data = CString::new(json_data_string)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&self.go_options())?)?;
+
+        unsafe {
+            OwnedGoResult(goffi::RenderWithOptions(
+                c_template.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
+        }
+        .into_result()
+    }
+
+    /// Executes the template rendering and returns the raw output bytes.
+    ///
+    /// Unlike [`render`](Self::render), this supports
+    /// [`output_encoding`](Self::output_encoding), whose output may not be valid UTF-8.
+    pub fn render_bytes(self) -> Result<Vec<u8>, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&self.go_options())?)?;
 
-        // Call Go function - 注意这里的转换
         let result = unsafe {
-            OwnedGoResult(goffi::RenderTemplate(
-                c_template.into_raw(),  // 使用 into_raw() 而不是 as_ptr() as *mut i8
-                c_json_data.into_raw(), // 使用 into_raw() 而不是 as_ptr() as *mut i8
+            OwnedGoBytes(goffi::RenderBytes(
+                c_template.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
+        };
+
+        let error = unsafe {
+            CStr::from_ptr(result.0.error)
+                .to_string_lossy()
+                .into_owned()
+        };
+        if !error.is_empty() {
+            return Err(RenderError::from_go(error));
+        }
+
+        let output = if result.0.length == 0 {
+            Vec::new()
+        } else {
+            unsafe {
+                std::slice::from_raw_parts(result.0.data as *const u8, result.0.length as usize)
+                    .to_vec()
+            }
+        };
+        Ok(output)
+    }
+
+    /// Executes the template rendering and returns the output split on `\n`.
+    ///
+    /// The lines do not contain the `\n`, but keep any `\r`. As with [`str::split`],
+    /// output ending in a newline yields a trailing empty line, and empty output
+    /// yields a single empty line.
+    pub fn render_lines(self) -> Result<Vec<String>, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&self.go_options())?)?;
+
+        let result = unsafe {
+            OwnedGoLines(goffi::RenderLines(
+                c_template.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
+        };
+
+        let error = unsafe {
+            CStr::from_ptr(result.0.error)
+                .to_string_lossy()
+                .into_owned()
+        };
+        if !error.is_empty() {
+            return Err(RenderError::from_go(error));
+        }
+
+        if result.0.count <= 0 {
+            return Ok(Vec::new());
+        }
+        let lines = unsafe {
+            std::slice::from_raw_parts(result.0.lines, result.0.count as usize)
+                .iter()
+                .map(|&line| CStr::from_ptr(line).to_string_lossy().into_owned())
+                .collect()
+        };
+        Ok(lines)
+    }
+
+    /// Renders the template, and renders `fallback_template` with the same data when it
+    /// fails.
+    ///
+    /// Only the options of Go's positional `RenderWithFallback` export apply: HTML
+    /// escaping, missing-key zero, `fail_on_empty_output`, `line_numbers`, `root_key` and
+    /// minification. Returns an error when the data is invalid or both templates fail.
+    pub fn render_with_fallback(
+        self,
+        fallback_template: &str,
+    ) -> Result<FallbackOutput, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_fallback = CString::new(fallback_template)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_root_key = CString::new(self.root_key)?;
+        let c_minify_type = CString::new(self.minify_type)?;
+
+        let (output, error) = unsafe {
+            OwnedGoResult(goffi::RenderWithFallback(
+                c_template.as_ptr() as *mut c_char,
+                c_fallback.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
                 self.escape_html,
                 self.use_missing_key_zero,
+                self.fail_on_empty_output,
+                self.line_numbers,
+                c_root_key.as_ptr() as *mut c_char,
+                c_minify_type.as_ptr() as *mut c_char,
+            ))
+        }
+        .into_parts();
+
+        if error.is_empty() {
+            return Ok(FallbackOutput {
+                output,
+                primary_error: None,
+            });
+        }
+        // 两个模板都失败或数据无效时没有输出；否则 error 为主模板的错误，output 来自备用模板
+        if error.starts_with("Fallback template failed: ")
+            || error.starts_with("Failed to unmarshal JSON data: ")
+        {
+            return Err(RenderError::from_go(error));
+        }
+        Ok(FallbackOutput {
+            output,
+            primary_error: Some(RenderError::from_go(error)),
+        })
+    }
+
+    /// Renders every non-empty template in the source with the same data.
+    ///
+    /// The template is parsed once; the root template (named `goTemplate`) and each
+    /// `{{define}}` block are rendered separately and keyed by name. A failure in one
+    /// template is reported only in that template's entry. The outer error covers
+    /// invalid data, options or template syntax.
+    pub fn render_all(self) -> Result<BTreeMap<String, Result<String, RenderError>>, RenderError> {
+        let outputs = serde_json::from_str(&self.call_render_all(false)?)?;
+        Ok(named_results(outputs))
+    }
+
+    /// Like [`render_all`](Self::render_all), but also returns a manifest of the
+    /// successfully rendered templates, sorted by name.
+    pub fn render_all_with_manifest(
+        self,
+    ) -> Result<
+        (
+            BTreeMap<String, Result<String, RenderError>>,
+            Vec<ManifestEntry>,
+        ),
+        RenderError,
+    > {
+        let output: GoManifestOutput<_> = serde_json::from_str(&self.call_render_all(true)?)?;
+        Ok((named_results(output.results), output.manifest))
+    }
+
+    fn call_render_all(&self, manifest: bool) -> Result<String, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&GoRenderOptions {
+            manifest,
+            ..self.go_options()
+        })?)?;
+
+        unsafe {
+            OwnedGoResult(goffi::RenderAll(
+                c_template.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
+        }
+        .into_result()
+    }
+
+    /// Renders the template once for every combination in a parameter matrix.
+    ///
+    /// The data must serialize to a JSON object mapping variable names to arrays of
+    /// values; each combination is rendered with those variables as its data. Combinations
+    /// are ordered with the variable names sorted and the last one varying fastest. A
+    /// failure in one combination is reported only in its [`MatrixOutput`]. The number of
+    /// combinations is limited to 1000.
+    pub fn render_matrix(self) -> Result<Vec<MatrixOutput>, RenderError> {
+        let outputs = serde_json::from_str(&self.call_render_matrix(false)?)?;
+        Ok(matrix_results(outputs))
+    }
+
+    /// Like [`render_matrix`](Self::render_matrix), but also returns a manifest of the
+    /// successfully rendered combinations, named by their index in the results.
+    pub fn render_matrix_with_manifest(
+        self,
+    ) -> Result<(Vec<MatrixOutput>, Vec<ManifestEntry>), RenderError> {
+        let output: GoManifestOutput<_> = serde_json::from_str(&self.call_render_matrix(true)?)?;
+        Ok((matrix_results(output.results), output.manifest))
+    }
+
+    fn call_render_matrix(&self, manifest: bool) -> Result<String, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_matrix = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&GoRenderOptions {
+            manifest,
+            ..self.go_options()
+        })?)?;
+
+        unsafe {
+            OwnedGoResult(goffi::RenderMatrix(
+                c_template.as_ptr() as *mut c_char,
+                c_matrix.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
+        }
+        .into_result()
+    }
+
+    /// Renders the template once for each element of the data, which must serialize to
+    /// a JSON array of objects.
+    ///
+    /// The template is parsed once. A failure in one element, including an element that is
+    /// not an object, is reported only in that element's entry; the outer error covers
+    /// invalid options, template syntax or malformed JSON.
+    pub fn render_batch(self) -> Result<Vec<Result<String, RenderError>>, RenderError> {
+        let outputs: Vec<GoUnitOutput> = serde_json::from_str(&self.call_render_batch(false)?)?;
+        Ok(outputs.into_iter().map(GoUnitOutput::into_result).collect())
+    }
+
+    /// Like [`render_batch`](Self::render_batch), but also returns a manifest of the
+    /// successfully rendered elements, named by their index in the array.
+    pub fn render_batch_with_manifest(
+        self,
+    ) -> Result<(Vec<Result<String, RenderError>>, Vec<ManifestEntry>), RenderError> {
+        let output: GoManifestOutput<Vec<GoUnitOutput>> =
+            serde_json::from_str(&self.call_render_batch(true)?)?;
+        let results = output.results.into_iter().map(GoUnitOutput::into_result);
+        Ok((results.collect(), output.manifest))
+    }
+
+    fn call_render_batch(&self, manifest: bool) -> Result<String, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&GoRenderOptions {
+            manifest,
+            ..self.go_options()
+        })?)?;
+
+        unsafe {
+            OwnedGoResult(goffi::RenderBatch(
+                c_template.as_ptr() as *mut c_char,
+                c_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+                None,
+                std::ptr::null_mut(),
+            ))
+        }
+        .into_result()
+    }
+
+    /// Like [`render_batch`](Self::render_batch), but passes each element's result to
+    /// `on_result` as soon as it is rendered instead of collecting them.
+    ///
+    /// `on_result` receives the element index and its output or error; returning `false`
+    /// stops the batch. A panic in `on_result` stops the batch and is resumed once Go
+    /// returns.
+    pub fn render_batch_each<F>(self, on_result: F) -> Result<BatchSummary, RenderError>
+    where
+        F: FnMut(usize, Result<&str, RenderError>) -> bool,
+    {
+        let c_template = CString::new(self.template_content)?;
+        let c_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&self.go_options())?)?;
+
+        let mut state = BatchCallback {
+            on_result,
+            panic: None,
+        };
+        let result = unsafe {
+            OwnedGoResult(goffi::RenderBatch(
+                c_template.as_ptr() as *mut c_char,
+                c_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+                Some(batch_write_trampoline::<F>),
+                &mut state as *mut BatchCallback<F> as *mut c_void,
             ))
         };
+        if let Some(payload) = state.panic {
+            panic::resume_unwind(payload);
+        }
+        Ok(serde_json::from_str(&result.into_result()?)?)
+    }
 
-        // Process result
-        let output = unsafe {
-            let output_str = CStr::from_ptr(result.0.output)
-                .to_string_lossy()
-                .into_owned();
-            // 重新获取所有权以便后续正确释放
-            let _ = CString::from_raw(result.0.output);
-            output_str
-        };
+    /// Starts an interactive render that asks for missing keys instead of failing.
+    ///
+    /// Missing keys are always treated as errors, so
+    /// [`use_missing_key_zero`](Self::use_missing_key_zero) has no effect. Each time
+    /// rendering reaches a key that is missing or `null`, a [`RenderSession`] reports its
+    /// path so the value can be supplied and rendering retried.
+    pub fn begin_session(self) -> Result<SessionState, RenderError> {
+        let c_template = CString::new(self.template_content)?;
+        let c_json_data = CString::new(serde_json::to_string(self.data)?)?;
+        let c_options = CString::new(serde_json::to_string(&self.go_options())?)?;
 
-        let error = unsafe {
-            let error_str = CStr::from_ptr(result.0.error)
-                .to_string_lossy()
-                .into_owned();
-            // 重新获取所有权以便后续正确释放
-            let _ = CString::from_raw(result.0.error);
-            error_str
+        let result = unsafe {
+            OwnedGoResult(goffi::BeginRenderSession(
+                c_template.as_ptr() as *mut c_char,
+                c_json_data.as_ptr() as *mut c_char,
+                c_options.as_ptr() as *mut c_char,
+            ))
         };
-
-        if !error.is_empty() {
-            Err(RenderError::GoExecution(error))
-        } else {
-            Ok(output)
-        }
+        SessionState::from_go(result, None)
     }
 }
 
@@ -435,6 +1460,667 @@ Field {{$i}}: {{$e}}
         assert_eq!(result, format!("Long: {}", long_name));
     }
 
+    // 空输出检测测试
+    #[test]
+    fn test_fail_on_empty_output() {
+        let data = SimpleData {
+            name: "Heidi".to_string(),
+            age: 33,
+            active: false,
+        };
+
+        let template = "{{if .active}}{{.name}}{{end}}";
+
+        // 默认情况下空输出是合法的
+        let result = TemplateRenderer::render_quick(template, &data).unwrap();
+        assert_eq!(result, "");
+
+        // 开启后空输出应当返回错误
+        let result = TemplateRenderer::new(template, &data)
+            .fail_on_empty_output(true)
+            .render();
+        if let Err(RenderError::GoExecution(err)) = result {
+            assert_eq!(err, "rendered output was empty");
+        } else {
+            panic!("Expected GoExecution error");
+        }
+    }
+
+    // 行号输出测试
+    #[test]
+    fn test_line_numbers() {
+        let data = EmptyData {};
+        let template = "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n";
+
+        let result = TemplateRenderer::new(template, &data)
+            .line_numbers(true)
+            .render()
+            .unwrap();
+
+        let lines: Vec<&str> = result.lines().collect();
+        assert_eq!(lines.len(), 10);
+        assert_eq!(lines[0], " 1 | a");
+        assert_eq!(lines[9], "10 | j");
+        assert!(result.ends_with('\n'));
+    }
+
+    // 根键包装测试
+    #[test]
+    fn test_root_key() {
+        let data = SimpleData {
+            name: "Ivan".to_string(),
+            age: 41,
+            active: true,
+        };
+
+        let template = "Hello, {{.Values.name}}!";
+        let result = TemplateRenderer::new(template, &data)
+            .root_key("Values")
+            .render()
+            .unwrap();
+
+        assert_eq!(result, "Hello, Ivan!");
+    }
+
+    // 输出压缩测试
+    #[test]
+    fn test_minify() {
+        let data = SimpleData {
+            name: "Judy".to_string(),
+            age: 27,
+            active: true,
+        };
+
+        let template = "{\n  \"name\": \"{{.name}}\",\n  \"age\": {{.age}}\n}\n";
+        let result = TemplateRenderer::new(template, &data)
+            .minify("json")
+            .render()
+            .unwrap();
+        assert_eq!(result, r#"{"name":"Judy","age":27}"#);
+
+        // 不支持的类型应当返回错误
+        let result = TemplateRenderer::new(template, &data)
+            .minify("xml")
+            .render();
+        assert!(result.is_err());
+    }
+
+    // 相对时间测试
+    #[test]
+    fn test_time_ago() {
+        let data = SimpleData {
+            name: "2024-01-01T09:00:00Z".to_string(),
+            age: 0,
+            active: true,
+        };
+
+        let template = "{{ timeAgo .name }}";
+        let result = TemplateRenderer::new(template, &data)
+            .reference_time("2024-01-01T12:00:00Z")
+            .render()
+            .unwrap();
+        assert_eq!(result, "3 hours ago");
+
+        let result = TemplateRenderer::new(template, &data)
+            .reference_time("2023-12-30T09:00:00Z")
+            .render()
+            .unwrap();
+        assert_eq!(result, "in 2 days");
+    }
+
+    // 数据预处理测试
+    #[test]
+    fn test_preprocess() {
+        let data: std::collections::HashMap<&str, &str> =
+            [("Name", "  Peggy ")].into_iter().collect();
+
+        let template = "[{{.name}}]";
+        let result = TemplateRenderer::new(template, &data)
+            .preprocess(&["lowercaseKeys", "trimStrings"])
+            .render()
+            .unwrap();
+        assert_eq!(result, "[Peggy]");
+
+        // 未知的变换名称应当返回错误
+        let result = TemplateRenderer::new(template, &data)
+            .preprocess(&["uppercase"])
+            .render();
+        assert!(result.is_err());
+    }
+
+    // 缺失值占位符测试
+    #[test]
+    fn test_missing_placeholder() {
+        let data: std::collections::HashMap<&str, &str> =
+            [("literal", "<no value>")].into_iter().collect();
+
+        let template = "[{{.missing}}] [{{.literal}}]";
+        let result = TemplateRenderer::new(template, &data)
+            .missing_placeholder("N/A")
+            .render()
+            .unwrap();
+        assert_eq!(result, "[N/A] [<no value>]");
+
+        let result = TemplateRenderer::new(template, &data)
+            .missing_placeholder("")
+            .render()
+            .unwrap();
+        assert_eq!(result, "[] [<no value>]");
+    }
+
+    // 输出编码测试
+    #[test]
+    fn test_output_encoding() {
+        let data: std::collections::HashMap<&str, &str> = [("word", "café")].into_iter().collect();
+
+        let result = TemplateRenderer::new("{{.word}}", &data)
+            .output_encoding("ISO-8859-1")
+            .render_bytes()
+            .unwrap();
+        assert_eq!(result, b"caf\xe9");
+
+        // 无法表示的字符默认视为错误，也可以选择替换
+        let template = "{{.word}} 中";
+        let result = TemplateRenderer::new(template, &data)
+            .output_encoding("latin1")
+            .render_bytes();
+        assert!(result.is_err());
+        let result = TemplateRenderer::new(template, &data)
+            .output_encoding("latin1")
+            .replace_unencodable(true)
+            .render_bytes()
+            .unwrap();
+        assert_eq!(result, b"caf\xe9 \x1a");
+
+        // render 不支持输出编码
+        let result = TemplateRenderer::new(template, &data)
+            .output_encoding("GBK")
+            .render();
+        assert!(result.is_err());
+    }
+
+    // 模板主动失败测试
+    #[test]
+    fn test_template_failure() {
+        let data: std::collections::HashMap<&str, i32> = [("replicas", 150)].into_iter().collect();
+
+        let result = TemplateRenderer::new(
+            r#"{{ failIf (gt .replicas 100.0) "too many replicas" }}ok"#,
+            &data,
+        )
+        .render();
+        match result {
+            Err(RenderError::TemplateFailure(msg)) => assert_eq!(msg, "too many replicas"),
+            other => panic!("expected a template failure, got {:?}", other),
+        }
+
+        // 引擎错误仍然是 GoExecution
+        let result = TemplateRenderer::new("{{ .replicas.x }}", &data).render();
+        assert!(matches!(result, Err(RenderError::GoExecution(_))));
+    }
+
+    // 按行输出测试
+    #[test]
+    fn test_render_lines() {
+        let data: std::collections::HashMap<&str, &str> = [("name", "gotpl")].into_iter().collect();
+
+        let lines = TemplateRenderer::new("a\n{{.name}}\r\nc", &data)
+            .render_lines()
+            .unwrap();
+        assert_eq!(lines, vec!["a", "gotpl\r", "c"]);
+
+        // 以换行符结尾时最后一个元素为空
+        let lines = TemplateRenderer::new("a\n", &data).render_lines().unwrap();
+        assert_eq!(lines, vec!["a", ""]);
+        let lines = TemplateRenderer::new("", &data).render_lines().unwrap();
+        assert_eq!(lines, vec![""]);
+
+        let result = TemplateRenderer::new("{{.name | nosuchfunc}}", &data).render_lines();
+        assert!(result.is_err());
+    }
+
+    // 换行符统一测试
+    #[test]
+    fn test_line_ending() {
+        let data: std::collections::HashMap<&str, &str> =
+            [("text", "a\r\nb\nc")].into_iter().collect();
+
+        let template = "{{.text}}";
+        let result = TemplateRenderer::new(template, &data)
+            .line_ending("crlf")
+            .render()
+            .unwrap();
+        assert_eq!(result, "a\r\nb\r\nc");
+
+        let result = TemplateRenderer::new(template, &data)
+            .line_ending("lf")
+            .render()
+            .unwrap();
+        assert_eq!(result, "a\nb\nc");
+    }
+
+    // 严格函数检查测试
+    #[test]
+    fn test_strict_funcs() {
+        let data = SimpleData {
+            name: "Nina".to_string(),
+            age: 29,
+            active: true,
+        };
+
+        let template = "{{ uper .name }} {{ lenght .name }} {{ len .name }}";
+        let result = TemplateRenderer::new(template, &data)
+            .strict_funcs(true)
+            .render();
+        match result {
+            Err(RenderError::GoExecution(msg)) => {
+                assert!(msg.contains("lenght, uper"), "unexpected error: {}", msg)
+            }
+            other => panic!("expected an execution error, got {:?}", other),
+        }
+    }
+
+    // 自定义分隔符测试
+    #[test]
+    fn test_custom_delimiters() {
+        let data = SimpleData {
+            name: "Mallory".to_string(),
+            age: 38,
+            active: true,
+        };
+
+        let template = "Hello, [[.name]]! {{ literal }}";
+        let result = TemplateRenderer::new(template, &data)
+            .delimiters("[[", "]]")
+            .render()
+            .unwrap();
+
+        assert_eq!(result, "Hello, Mallory! {{ literal }}");
+    }
+
+    // 输出大小限制测试
+    #[test]
+    fn test_max_output_bytes() {
+        let data = EmptyData {};
+        let template = "0123456789";
+
+        let result = TemplateRenderer::new(template, &data)
+            .max_output_bytes(10)
+            .render()
+            .unwrap();
+        assert_eq!(result, "0123456789");
+
+        let result = TemplateRenderer::new(template, &data)
+            .max_output_bytes(5)
+            .render();
+        if let Err(RenderError::GoExecution(err)) = result {
+            assert!(err.contains("exceeds 5 bytes"));
+        } else {
+            panic!("Expected GoExecution error");
+        }
+    }
+
+    // 预览截断测试
+    #[test]
+    fn test_preview_bytes() {
+        let data = EmptyData {};
+
+        let result = TemplateRenderer::new("0123456789", &data)
+            .preview_bytes(10)
+            .render()
+            .unwrap();
+        assert_eq!(result, "0123456789");
+
+        let result = TemplateRenderer::new("0123456789", &data)
+            .preview_bytes(4)
+            .render()
+            .unwrap();
+        assert_eq!(result, "0123... (truncated)");
+
+        // 不会截断多字节字符
+        let result = TemplateRenderer::new("ab中文", &data)
+            .preview_bytes(4)
+            .render()
+            .unwrap();
+        assert_eq!(result, "ab... (truncated)");
+    }
+
+    // 输出过滤器测试
+    #[test]
+    fn test_post_filters() {
+        let data = EmptyData {};
+
+        // 过滤器按给出的顺序应用
+        let result = TemplateRenderer::new("  a  \n\n", &data)
+            .post_filters(&["trim", "ensureTrailingNewline"])
+            .render()
+            .unwrap();
+        assert_eq!(result, "a\n");
+
+        let result = TemplateRenderer::new("  a  \n\n", &data)
+            .post_filters(&["ensureTrailingNewline", "trim"])
+            .render()
+            .unwrap();
+        assert_eq!(result, "a");
+
+        // 未知的过滤器名称应当返回错误
+        let result = TemplateRenderer::new("a", &data)
+            .post_filters(&["trim", "upper"])
+            .render();
+        assert!(result.is_err());
+    }
+
+    // 校验和注释测试
+    #[test]
+    fn test_embed_checksum() {
+        let data = EmptyData {};
+
+        // 空内容的 SHA-256
+        let result = TemplateRenderer::new("", &data)
+            .embed_checksum("#")
+            .render()
+            .unwrap();
+        assert_eq!(
+            result,
+            "# gotpl-checksum: sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855\n"
+        );
+
+        let result = TemplateRenderer::new("<p>hi</p>", &data)
+            .embed_checksum("<!--")
+            .render()
+            .unwrap();
+        assert!(result.starts_with("<p>hi</p>\n<!-- gotpl-checksum: sha256:"));
+        assert!(result.ends_with(" -->\n"));
+
+        // 未知的注释前缀应当返回错误
+        let result = TemplateRenderer::new("x", &data)
+            .embed_checksum(";")
+            .render();
+        assert!(result.is_err());
+    }
+
+    // renderString 开关测试
+    #[test]
+    fn test_allow_render_string() {
+        let data: std::collections::HashMap<&str, &str> =
+            [("intro", "Hello {{ .name }}"), ("name", "Ada")]
+                .into_iter()
+                .collect();
+        let template = "{{ renderString .intro . }}";
+
+        // 默认不可用
+        let result = TemplateRenderer::new(template, &data).render();
+        assert!(result.is_err());
+
+        let result = TemplateRenderer::new(template, &data)
+            .allow_render_string(true)
+            .render()
+            .unwrap();
+        assert_eq!(result, "Hello Ada");
+    }
+
+    // 命名常量测试
+    #[test]
+    fn test_constants() {
+        let data: std::collections::HashMap<&str, i32> = [("a", 1)].into_iter().collect();
+        let constants = serde_json::json!({ "ENV": "prod", "BETA": true });
+
+        let template = r#"{{ const "ENV" }}{{ if const "BETA" }} beta{{ end }} {{ range $k, $v := . }}{{ $k }}{{ end }}"#;
+        let result = TemplateRenderer::new(template, &data)
+            .constants(&constants)
+            .render()
+            .unwrap();
+        assert_eq!(result, "prod beta a");
+
+        // 未定义的常量应当返回错误
+        let result = TemplateRenderer::new(r#"{{ const "EVN" }}"#, &data)
+            .constants(&constants)
+            .render();
+        assert!(result.is_err());
+    }
+
+    // 模板集合渲染测试
+    #[test]
+    fn test_render_all() {
+        let data: std::collections::HashMap<&str, &str> = [("name", "Ada")].into_iter().collect();
+        let template = r#"{{ define "b" }}B {{ .name }}{{ end }}{{ define "a" }}A {{ .name }}{{ end }}{{ define "bad" }}{{ fail "boom" }}{{ end }}"#;
+
+        let outputs = TemplateRenderer::new(template, &data).render_all().unwrap();
+        let names: Vec<&str> = outputs.keys().map(String::as_str).collect();
+        assert_eq!(names, ["a", "b", "bad"]);
+        assert_eq!(outputs["a"].as_ref().unwrap(), "A Ada");
+        assert_eq!(outputs["b"].as_ref().unwrap(), "B Ada");
+        match &outputs["bad"] {
+            Err(RenderError::TemplateFailure(msg)) => assert_eq!(msg, "boom"),
+            other => panic!("Expected TemplateFailure, got {:?}", other),
+        }
+
+        // 语法错误时整体返回错误
+        let result = TemplateRenderer::new(r#"{{ define "a" }}{{ .name "#, &data).render_all();
+        assert!(result.is_err());
+    }
+
+    // 参数矩阵渲染测试
+    #[test]
+    fn test_render_matrix() {
+        let matrix = serde_json::json!({ "os": ["linux", "mac"], "arch": ["amd64", "arm64"] });
+        let template = r#"{{ if eq .os "mac" }}{{ if eq .arch "amd64" }}{{ fail "unsupported" }}{{ end }}{{ end }}{{ .os }}-{{ .arch }}"#;
+
+        let outputs = TemplateRenderer::new(template, &matrix)
+            .render_matrix()
+            .unwrap();
+        assert_eq!(outputs.len(), 4);
+        // 变量名排序后 os 在最后，变化最快
+        assert_eq!(outputs[0].result.as_ref().unwrap(), "linux-amd64");
+        assert_eq!(outputs[2].result.as_ref().unwrap(), "linux-arm64");
+        assert_eq!(outputs[3].result.as_ref().unwrap(), "mac-arm64");
+        assert_eq!(outputs[1].params["os"], "mac");
+        assert!(outputs[1].result.is_err());
+
+        // 取值不是数组时整体返回错误
+        let result =
+            TemplateRenderer::new(template, &serde_json::json!({ "os": "linux" })).render_matrix();
+        assert!(result.is_err());
+    }
+
+    // 交互式渲染会话测试
+    #[test]
+    fn test_render_session() {
+        let data = serde_json::json!({ "user": { "name": "Ada" } });
+        let template = "{{ .user.name }} <{{ .user.email }}> {{ .team }}";
+
+        let mut state = TemplateRenderer::new(template, &data)
+            .begin_session()
+            .unwrap();
+        let mut asked = Vec::new();
+        let output = loop {
+            match state {
+                SessionState::Done(output) => break output,
+                SessionState::NeedsKey(session) => {
+                    let path = serde_json::to_string(session.missing_path()).unwrap();
+                    let value = if path.contains("email") {
+                        "ada@example.com"
+                    } else {
+                        "core"
+                    };
+                    asked.push(path);
+                    state = session.provide(&value).unwrap();
+                }
+            }
+        };
+        assert_eq!(output, "Ada <ada@example.com> core");
+        assert_eq!(asked, [r#"["user","email"]"#, r#"["team"]"#]);
+
+        // 放弃未完成的会话
+        match TemplateRenderer::new(template, &data)
+            .begin_session()
+            .unwrap()
+        {
+            SessionState::NeedsKey(session) => drop(session),
+            SessionState::Done(output) => panic!("Expected a missing key, got {:?}", output),
+        }
+    }
+
+    // 批量渲染测试
+    #[test]
+    fn test_render_batch() {
+        let rows = serde_json::json!([{ "n": 1 }, "oops", { "n": 3 }]);
+        let template = "row {{ .n }}";
+
+        let results = TemplateRenderer::new(template, &rows)
+            .render_batch()
+            .unwrap();
+        assert_eq!(results.len(), 3);
+        assert_eq!(results[0].as_ref().unwrap(), "row 1");
+        assert!(results[1].is_err());
+        assert_eq!(results[2].as_ref().unwrap(), "row 3");
+
+        // 回调逐个接收结果，返回 false 时停止
+        let mut seen = Vec::new();
+        let summary = TemplateRenderer::new(template, &rows)
+            .render_batch_each(|index, result| {
+                seen.push((index, result.map(str::to_string).ok()));
+                index < 1
+            })
+            .unwrap();
+        assert_eq!(seen, [(0, Some("row 1".to_string())), (1, None)]);
+        assert_eq!(summary.count, 2);
+        assert_eq!(summary.failed, 1);
+        assert!(summary.stopped);
+
+        // 回调中的 panic 在 Go 返回后继续传播
+        let panicked = std::panic::catch_unwind(|| {
+            TemplateRenderer::new(template, &rows)
+                .render_batch_each(|_, _| panic!("callback failed"))
+        });
+        assert!(panicked.is_err());
+
+        // 数据不是数组时整体返回错误
+        let result = TemplateRenderer::new(template, &serde_json::json!({ "n": 1 })).render_batch();
+        assert!(result.is_err());
+    }
+
+    // 模板集检查测试
+    #[test]
+    fn test_check_template_set() {
+        let files = [
+            ("base.tmpl", r#"{{ define "row" }}{{ slugify . }}{{ end }}"#),
+            (
+                "page.tmpl",
+                r#"{{ template "row" .name }}{{ template "missing" }}"#,
+            ),
+            ("broken.tmpl", "{{ if }}"),
+        ];
+
+        let report = check_template_set(&files, None).unwrap();
+        assert!(!report.ok);
+        assert_eq!(report.files.len(), 3);
+        assert!(report.files[0].parse_error.is_none());
+        assert_eq!(report.files[1].unresolved_templates, ["missing"]);
+        assert!(report.files[2].parse_error.is_some());
+
+        // 限制允许的函数
+        let report = check_template_set(&files[..1], Some(&["snakecase"])).unwrap();
+        assert!(!report.ok);
+        assert_eq!(report.files[0].disallowed_funcs, ["slugify"]);
+
+        let report = check_template_set(&files[..1], Some(&["slugify"])).unwrap();
+        assert!(report.ok);
+    }
+
+    // 渲染清单测试
+    #[test]
+    fn test_render_with_manifest() {
+        let entry = |name: &str, length, sha256: &str| ManifestEntry {
+            name: name.to_string(),
+            length,
+            sha256: sha256.to_string(),
+        };
+        // "a" 和 "bb" 的 SHA-256
+        let a = "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb";
+        let bb = "3b64db95cb55c763391c707108489ae18b4112d783300de38e033b4c98c3deaf";
+
+        let template = r#"{{ define "x" }}a{{ end }}{{ define "y" }}bb{{ end }}{{ define "z" }}{{ fail "boom" }}{{ end }}"#;
+        let (outputs, manifest) = TemplateRenderer::new(template, &serde_json::json!({}))
+            .render_all_with_manifest()
+            .unwrap();
+        assert!(outputs["z"].is_err());
+        assert_eq!(manifest, [entry("x", 1, a), entry("y", 2, bb)]);
+
+        let matrix = serde_json::json!({ "v": ["a", "", "bb"] });
+        let (outputs, manifest) = TemplateRenderer::new("{{ .v }}", &matrix)
+            .fail_on_empty_output(true)
+            .render_matrix_with_manifest()
+            .unwrap();
+        assert_eq!(outputs.len(), 3);
+        assert_eq!(manifest, [entry("0", 1, a), entry("2", 2, bb)]);
+
+        let rows = serde_json::json!([{ "v": "a" }, "oops", { "v": "bb" }]);
+        let (results, manifest) = TemplateRenderer::new("{{ .v }}", &rows)
+            .render_batch_with_manifest()
+            .unwrap();
+        assert!(results[1].is_err());
+        assert_eq!(manifest, [entry("0", 1, a), entry("2", 2, bb)]);
+    }
+
+    // 备用模板测试
+    #[test]
+    fn test_render_with_fallback() {
+        let data = serde_json::json!({ "name": "x" });
+
+        let result = TemplateRenderer::new("hi {{ .name }}", &data)
+            .render_with_fallback("fallback")
+            .unwrap();
+        assert_eq!(result.output, "hi x");
+        assert!(result.primary_error.is_none());
+
+        // 主模板失败时使用备用模板，并保留主模板的错误
+        let result = TemplateRenderer::new(r#"{{ fail "boom" }}"#, &data)
+            .render_with_fallback("fallback {{ .name }}")
+            .unwrap();
+        assert_eq!(result.output, "fallback x");
+        assert!(matches!(
+            result.primary_error,
+            Some(RenderError::TemplateFailure(ref msg)) if msg == "boom"
+        ));
+
+        // 两者都失败
+        let result = TemplateRenderer::new(r#"{{ fail "first" }}"#, &data)
+            .render_with_fallback(r#"{{ fail "second" }}"#);
+        match result {
+            Err(RenderError::GoExecution(msg)) => {
+                assert!(msg.contains("second") && msg.contains("first"), "{msg}")
+            }
+            other => panic!("expected both templates to fail, got {other:?}"),
+        }
+    }
+
+    // 日志回调测试
+    #[test]
+    fn test_log_callback() {
+        use std::sync::Mutex;
+
+        let logs = Arc::new(Mutex::new(Vec::new()));
+        let sink = Arc::clone(&logs);
+        set_log_callback(move |level, message| {
+            sink.lock().unwrap().push((level, message.to_string()));
+        });
+        let _ = TemplateRenderer::new(
+            r#"{{ fail "log callback marker" }}"#,
+            &serde_json::json!({}),
+        )
+        .render();
+        clear_log_callback();
+
+        // 其他测试并发渲染时也会产生日志，只检查本次渲染的那一条
+        let logs = logs.lock().unwrap();
+        assert!(
+            logs.iter().any(|(level, message)| *level == LogLevel::Error
+                && message.contains("log callback marker")),
+            "{logs:?}"
+        );
+    }
+
     // 序列化错误测试
     #[test]
     fn test_serialization_error() {